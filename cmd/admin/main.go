@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mhvn092/movie-go/internal/domain/throttle"
+	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/platform/database"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+	"github.com/mhvn092/movie-go/pkg/mailer"
+)
+
+// passwordEnv is read when the password is not given as a flag, so it stays out of the
+// shell history and the process list.
+const passwordEnv = "ADMIN_PASSWORD"
+
+const usage = `usage:
+  admin create -email <email> -phone <phone> [-first-name <name>] [-last-name <name>] [-password <password>]
+  admin reset -email <email> [-password <password>]
+
+The password is taken from -password, then the ADMIN_PASSWORD environment variable, and
+asked for otherwise.`
+
+var stdin = bufio.NewReader(os.Stdin)
+
+func main() {
+	if len(os.Args) < 2 {
+		fail(errors.New(usage))
+	}
+
+	switch os.Args[1] {
+	case "create":
+		handleCreateCommand(os.Args[2:])
+	case "reset":
+		handleResetCommand(os.Args[2:])
+	default:
+		fail(errors.New(usage))
+	}
+}
+
+// handleCreateCommand adds a new admin with a verified email.
+func handleCreateCommand(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	email := fs.String("email", "", "email of the admin")
+	firstName := fs.String("first-name", "", "first name of the admin")
+	lastName := fs.String("last-name", "", "last name of the admin")
+	phone := fs.String("phone", "", "phone number of the admin")
+	password := fs.String("password", "", "password of the admin")
+	fs.Parse(args)
+
+	u := &user.User{
+		FirstName:   *firstName,
+		LastName:    *lastName,
+		Email:       *email,
+		PhoneNumber: *phone,
+		Password:    readPassword(*password),
+	}
+	if errs := validator.Validate(u); len(errs) > 0 {
+		failValidation(errs)
+	}
+
+	service := newUserService()
+	if err := service.CreateAdmin(u); err != nil {
+		fail(err)
+	}
+	fmt.Printf("admin %s created with id %d\n", u.Email, u.Id)
+}
+
+// handleResetCommand sets a new password for an existing user, makes them an admin and
+// enables them again, for when nobody can log in as an admin anymore.
+func handleResetCommand(args []string) {
+	fs := flag.NewFlagSet("reset", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user")
+	password := fs.String("password", "", "new password of the user")
+	fs.Parse(args)
+
+	input := struct {
+		Email    string `json:"email"    validate:"required, is_string, is_email"`
+		Password string `json:"password" validate:"required, is_string, is_strong_password,min_len=10"`
+	}{Email: *email, Password: readPassword(*password)}
+	if errs := validator.Validate(&input); len(errs) > 0 {
+		failValidation(errs)
+	}
+
+	service := newUserService()
+	if err := service.ResetAdmin(input.Email, input.Password); err != nil {
+		fail(err)
+	}
+	fmt.Printf("admin %s reset, their sessions have been ended\n", input.Email)
+}
+
+func newUserService() *user.UserService {
+	db := database.InitDb()
+
+	m, err := mailer.NewFromEnv()
+	if err != nil {
+		fail(err)
+	}
+
+	base := &repository.BaseRepository{DB: db}
+	throttleService := throttle.NewThrottleService(throttle.NewThrottleRepository(base))
+	return user.NewUserService(user.NewUserRepository(base), m, throttleService)
+}
+
+// readPassword returns the password from the flag or the environment, and asks for it
+// twice otherwise.
+func readPassword(fromFlag string) string {
+	if fromFlag != "" {
+		return fromFlag
+	}
+	if password, ok := os.LookupEnv(passwordEnv); ok {
+		return password
+	}
+
+	password := prompt("Password: ")
+	if password != prompt("Repeat password: ") {
+		fail(errors.New("passwords do not match"))
+	}
+	return password
+}
+
+// prompt reads a line from stdin, without echoing it when stdin is a terminal.
+func prompt(label string) string {
+	fmt.Fprint(os.Stderr, label)
+
+	if isTerminal() && setEcho(false) == nil {
+		defer func() {
+			setEcho(true)
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fail(fmt.Errorf("could not read the password: %w", err))
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func isTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func setEcho(on bool) error {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+func failValidation(errs []exception.FieldError) {
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", e.Field, e.Message)
+	}
+	os.Exit(1)
+}
+
+// fail reports the error and exits. exception.ErrorExit only prints in development,
+// which would hide why a command run by hand failed.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -2,69 +2,248 @@ package main
 
 import (
 	"errors"
+	"flag"
+	"fmt"
+	"log"
 	"os"
 	"strings"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+
 	"github.com/mhvn092/movie-go/internal/platform/database"
-	"github.com/mhvn092/movie-go/pkg/exception"
 	"github.com/mhvn092/movie-go/pkg/migration"
 )
 
-var validCommand = [3]string{"up", "down", "create"}
+// The exit codes CI can tell apart: a failed command exits with 1 and a command that can't
+// be understood with 2.
+const (
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// migrationsDir is read from the working directory rather than the embedded files, so
+// migrations being written are picked up without a rebuild.
+const migrationsDir = "migrations"
+
+const usage = `usage:
+  migration create -name <name>
+  migration status
+  migration up [--to <version>] [--dry-run]
+  migration down [--steps <n> | --to <version>] [--dry-run]
+  migration redo [--dry-run]
+  migration verify
+  migration repair [--version <version> [--forget-missing]]
+
+up applies every pending migration, or those up to and including --to.
+down reverts the last migration, the last --steps ones, or every one after --to.
+redo reverts the last migration and applies it again.
+--dry-run prints the statements that would run instead of running them.
+verify reports applied migrations whose files were changed or deleted since, and those
+without a recorded checksum, without changing the database; up, down and redo refuse to run
+while files were changed or deleted, and record the missing checksums.
+repair records the files of the applied migrations, or only --version, as they are now.
+Applied migrations whose file is missing block every run until the file is restored, or the
+migration is forgotten with --version and --forget-missing. That only deletes its row; the
+changes it made stay in the database.
+
+Exit codes: 0 on success, 1 when a migration fails or verify finds drift, 2 on invalid arguments.`
 
 func main() {
 	if len(os.Args) < 2 {
-		exception.ErrorExit(errors.New("no args provided"), "you should provide the arguments")
-	}
-
-	command := os.Args[1]
-	if !isValidCommand(command) {
-		exception.ErrorExit(errors.New("args not valid"), "you should provide valid arguments")
+		usageExit(errors.New("no command provided"))
 	}
 
+	command, args := os.Args[1], os.Args[2:]
 	switch command {
 	case "create":
-		handleCreateCommand(os.Args[2:])
+		handleCreateCommand(args)
+	case "status":
+		handleStatusCommand(args)
 	case "up":
-		handleUpCommand()
+		handleUpCommand(args)
 	case "down":
-		handleDownCommand()
+		handleDownCommand(args)
+	case "redo":
+		handleRedoCommand(args)
+	case "verify":
+		handleVerifyCommand(args)
+	case "repair":
+		handleRepairCommand(args)
 	default:
-		exception.ErrorExit(errors.New("unknown command"), "unknown command")
+		usageExit(fmt.Errorf("unknown command %q", command))
 	}
 }
 
-func isValidCommand(command string) bool {
-	for _, valid := range validCommand {
-		if valid == command {
-			return true
+func handleCreateCommand(args []string) {
+	if len(args) < 2 {
+		usageExit(errors.New("no name provided"))
+	}
+
+	name, err := migration.CreateMigrationFile(migrationsDir, strings.Join(args[1:], " "))
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println("migration file with the name " + name + " is created")
+}
+
+func handleStatusCommand(args []string) {
+	parseFlags("status", args)
+
+	withMigrator(func(m *migration.Migrator) error {
+		statuses, err := m.Status()
+		if err != nil {
+			return err
 		}
+		migration.PrintStatus(os.Stdout, statuses)
+		return nil
+	})
+}
+
+func handleUpCommand(args []string) {
+	fs := newFlagSet("up")
+	to := fs.Int("to", 0, "apply the pending migrations up to and including this version")
+	dryRun := fs.Bool("dry-run", false, "print the statements instead of running them")
+	parse(fs, args)
+
+	if *to < 0 {
+		usageExit(errors.New("--to must be a migration version"))
 	}
-	return false
+
+	withMigrator(func(m *migration.Migrator) error {
+		return m.Up(migration.UpOptions{To: *to, DryRun: *dryRun})
+	})
 }
 
-func handleCreateCommand(args []string) {
-	if len(args) < 2 {
-		exception.ErrorExit(errors.New("no name provided"), "you should provide the name")
+func handleDownCommand(args []string) {
+	fs := newFlagSet("down")
+	steps := fs.Int("steps", 1, "how many of the last migrations to revert")
+	to := fs.Int("to", 0, "revert every migration after this version, 0 for all of them")
+	dryRun := fs.Bool("dry-run", false, "print the statements instead of running them")
+	parse(fs, args)
+
+	opts := migration.DownOptions{Steps: *steps, DryRun: *dryRun}
+	stepsSet := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "to":
+			opts.To = to
+		case "steps":
+			stepsSet = true
+		}
+	})
+
+	if opts.To != nil && stepsSet {
+		usageExit(errors.New("--steps and --to can't be used together"))
+	}
+	if *steps < 1 || *to < 0 {
+		usageExit(errors.New("--steps must be positive and --to a migration version"))
 	}
 
-	name := strings.Join(args[1:], " ")
+	withMigrator(func(m *migration.Migrator) error {
+		return m.Down(opts)
+	})
+}
+
+func handleRedoCommand(args []string) {
+	fs := newFlagSet("redo")
+	dryRun := fs.Bool("dry-run", false, "print the statements instead of running them")
+	parse(fs, args)
+
+	withMigrator(func(m *migration.Migrator) error {
+		return m.Redo(*dryRun)
+	})
+}
+
+func handleVerifyCommand(args []string) {
+	parseFlags("verify", args)
+
+	withMigrator(func(m *migration.Migrator) error {
+		drifts, err := m.Verify()
+		if err != nil {
+			return err
+		}
+		migration.PrintDrift(os.Stdout, drifts)
+		if len(drifts) > 0 {
+			return errors.New("applied migrations failed verification")
+		}
+		return nil
+	})
+}
+
+func handleRepairCommand(args []string) {
+	fs := newFlagSet("repair")
+	version := fs.Int("version", 0, "only repair the migration with this version")
+	forgetMissing := fs.Bool("forget-missing", false, "forget the migration --version, whose file is missing")
+	parse(fs, args)
 
-	if name == "" {
-		exception.ErrorExit(errors.New("no name provided"), "you should provide the name")
+	if *version < 0 {
+		usageExit(errors.New("--version must be a migration version"))
+	}
+	if *forgetMissing && *version == 0 {
+		usageExit(errors.New("--forget-missing needs --version"))
 	}
 
-	migration.CreateMigrationFile(name)
+	withMigrator(func(m *migration.Migrator) error {
+		repaired, err := m.Repair(migration.RepairOptions{Version: *version, ForgetMissing: *forgetMissing})
+		if err != nil {
+			return err
+		}
+		if len(repaired) == 0 {
+			fmt.Println("no checksum to repair")
+		}
+		for _, name := range repaired {
+			if *forgetMissing {
+				fmt.Printf("Forgot migration: %s\n", name)
+				continue
+			}
+			fmt.Printf("Repaired the checksum of migration: %s\n", name)
+		}
+		return nil
+	})
 }
 
-func handleUpCommand() {
+// withMigrator runs the command against the database and exits with its error.
+func withMigrator(run func(m *migration.Migrator) error) {
 	conn := database.InitDb()
-	defer conn.Close()
-	migration.RunMigrations(conn)
+	err := run(newMigrator(conn))
+	conn.Close()
+	if err != nil {
+		fail(err)
+	}
 }
 
-func handleDownCommand() {
-	conn := database.InitDb()
-	defer conn.Close()
-	migration.RevertTheLastMigration(conn)
+func newMigrator(conn *pgxpool.Pool) *migration.Migrator {
+	return migration.NewMigrator(os.DirFS(migrationsDir), conn, log.New(os.Stdout, "", 0))
+}
+
+func newFlagSet(command string) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func parseFlags(command string, args []string) {
+	parse(newFlagSet(command), args)
+}
+
+func parse(fs *flag.FlagSet, args []string) {
+	if err := fs.Parse(args); err != nil {
+		usageExit(err)
+	}
+	if fs.NArg() > 0 {
+		usageExit(fmt.Errorf("unexpected arguments %v", fs.Args()))
+	}
+}
+
+// usageExit reports arguments that can't be understood.
+func usageExit(err error) {
+	fmt.Fprintf(os.Stderr, "%v\n\n%s\n", err, usage)
+	os.Exit(exitUsage)
+}
+
+// fail reports why the command failed. exception.ErrorExit only prints in development, and
+// CI needs to see it.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(exitFailure)
 }
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"log"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/mhvn092/movie-go/internal/platform/config"
 	"github.com/mhvn092/movie-go/internal/platform/database"
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/internal/platform/web"
 	root "github.com/mhvn092/movie-go/internal/transport/http"
+	"github.com/mhvn092/movie-go/migrations"
+	"github.com/mhvn092/movie-go/pkg/env"
 	"github.com/mhvn092/movie-go/pkg/exception"
+	"github.com/mhvn092/movie-go/pkg/mailer"
+	"github.com/mhvn092/movie-go/pkg/migration"
 	"github.com/mhvn092/movie-go/pkg/router"
 )
 
@@ -22,11 +30,31 @@ func main() {
 func initialize() (*pgxpool.Pool, string, *router.Router) {
 	conn := database.InitDb()
 
+	if env.GetEnv(env.AUTO_MIGRATE) == "true" {
+		autoMigrate(conn)
+	}
+
+	security.InitKeySet()
+	web.InitTrustedProxies()
+	web.InitCursorSecret()
+
 	url, r := root.CreateServer()
 
-	config.InitializeAppConfig(r, conn)
+	m, err := mailer.NewFromEnv()
+	exception.ErrorExit(err, "could not set up the mailer")
+
+	config.InitializeAppConfig(r, conn, m)
 
 	root.InitializeRoutes()
 
 	return conn, url, r
 }
+
+// autoMigrate applies the pending migrations embedded in the binary before serving. Replicas
+// starting together take turns through the migration lock.
+func autoMigrate(conn *pgxpool.Pool) {
+	m := migration.NewMigrator(migrations.FS, conn, log.Default())
+	if err := m.Up(migration.UpOptions{}); err != nil {
+		log.Fatalf("could not apply the migrations: %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package apikey
+
+type ApiKeyUpsertPayload struct {
+	Name      string   `json:"name"       validate:"required, is_string, max_len=255"`
+	Scopes    []string `json:"scopes"     validate:"required"`
+	ExpiresAt string   `json:"expires_at" validate:"omitempty, is_datetime_string"`
+}
+
+// ApiKeyCreatedResponse is the only time the key itself is shown, only its hash is kept.
+type ApiKeyCreatedResponse struct {
+	Id  int    `json:"id"`
+	Key string `json:"key"`
+}
@@ -0,0 +1,19 @@
+package apikey
+
+import "time"
+
+// keyPrefix marks the keys of this api, so a leaked one is easy to recognize.
+const keyPrefix = "mgk_"
+
+type ApiKey struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	UsageCount int64      `json:"usage_count"`
+}
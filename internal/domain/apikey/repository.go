@@ -0,0 +1,196 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type ApiKeyRepository struct {
+	*repository.BaseRepository
+}
+
+// ListPagination lists the fields api keys can be sorted by, newest first by default.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":         {Expr: "id", Type: "integer"},
+		"name":       {Expr: "name", Type: "text"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	DefaultSort: "-id",
+}
+
+func NewApiKeyRepository(base *repository.BaseRepository) *ApiKeyRepository {
+	return &ApiKeyRepository{BaseRepository: base}
+}
+
+func (r *ApiKeyRepository) getAllPaginated(params web.PaginationParam) (page web.Page[ApiKey], err error) {
+	keyset, err := web.NewKeyset(params, ListPagination, 1)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf(
+			`select id, name, prefix, scopes, created_by, created_at, expires_at, revoked_at, last_used_at, usage_count, %s
+    from person.api_key
+    %s
+    order by %s
+    limit %d`,
+			keyset.SelectColumns(),
+			web.WhereClause(keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		keyset.Args()...,
+	)
+	if err != nil {
+		return page, err
+	}
+
+	defer rows.Close()
+
+	res := []ApiKey{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item ApiKey
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(
+			append([]interface{}{
+				&item.Id,
+				&item.Name,
+				&item.Prefix,
+				&item.Scopes,
+				&item.CreatedBy,
+				&item.CreatedAt,
+				&item.ExpiresAt,
+				&item.RevokedAt,
+				&item.LastUsedAt,
+				&item.UsageCount,
+			}, keyDest...)...,
+		)
+		if err != nil {
+			return
+		}
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from person.api_key")
+	}
+
+	return
+}
+
+// findUnknownScopes returns the scopes that are not a permission.
+func (r *ApiKeyRepository) findUnknownScopes(scopes []string) ([]string, error) {
+	rows, err := r.DB.Query(
+		context.Background(),
+		`select scope from unnest($1::varchar[]) as scope
+    where not exists (select 1 from person.permission where name = scope)`,
+		scopes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r *ApiKeyRepository) insert(
+	name string,
+	scopes []string,
+	expiresAt *time.Time,
+	createdBy int,
+	prefix string,
+	keyHash string,
+) (int, error) {
+	var keyId int
+	err := r.DB.QueryRow(
+		context.Background(),
+		`insert into person.api_key (name, scopes, expires_at, created_by, prefix, key_hash)
+    values ($1, $2, $3, nullif($4, 0), $5, $6) returning id`,
+		name,
+		scopes,
+		expiresAt,
+		createdBy,
+		prefix,
+		keyHash,
+	).Scan(&keyId)
+	return keyId, err
+}
+
+func (r *ApiKeyRepository) edit(id int, name string, scopes []string, expiresAt *time.Time) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"update person.api_key set name = $1, scopes = $2, expires_at = $3 where id = $4",
+		name,
+		scopes,
+		expiresAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("api key", id)
+	}
+
+	return nil
+}
+
+// revoke stops the key from working for good. Revoking it again keeps the first time.
+func (r *ApiKeyRepository) revoke(id int) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"update person.api_key set revoked_at = coalesce(revoked_at, $1) where id = $2",
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("api key", id)
+	}
+
+	return nil
+}
+
+// authenticate finds the usable key with keyHash and counts the use on it, or returns nil.
+func (r *ApiKeyRepository) authenticate(keyHash string) (*ApiKey, error) {
+	now := time.Now().UTC()
+
+	var key ApiKey
+	err := r.DB.QueryRow(
+		context.Background(),
+		`update person.api_key set last_used_at = $2, usage_count = usage_count + 1
+    where key_hash = $1 and revoked_at is null and (expires_at is null or expires_at > $2)
+    returning id, name, scopes`,
+		keyHash,
+		now,
+	).Scan(&key.Id, &key.Name, &key.Scopes)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
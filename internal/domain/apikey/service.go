@@ -0,0 +1,122 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type ApiKeyService struct {
+	repo *ApiKeyRepository
+}
+
+func NewApiKeyService(repo *ApiKeyRepository) *ApiKeyService {
+	return &ApiKeyService{repo: repo}
+}
+
+func (s *ApiKeyService) GetAllPaginated(p web.PaginationParam) (web.Page[ApiKey], error) {
+	return s.repo.getAllPaginated(p)
+}
+
+// Insert creates a key granting the scopes, which are permission names like "movie:create".
+// Callers can only grant permissions they have themselves.
+func (s *ApiKeyService) Insert(claims *security.UserClaims, payload *ApiKeyUpsertPayload) (ApiKeyCreatedResponse, error) {
+	expiresAt, err := s.preparePayload(claims, payload)
+	if err != nil {
+		return ApiKeyCreatedResponse{}, err
+	}
+
+	token, _, err := security.NewOpaqueToken()
+	if err != nil {
+		return ApiKeyCreatedResponse{}, err
+	}
+	key := keyPrefix + token
+
+	keyId, err := s.repo.insert(
+		payload.Name,
+		payload.Scopes,
+		expiresAt,
+		claims.Id,
+		key[:len(keyPrefix)+6],
+		security.HashToken(key),
+	)
+	if err != nil {
+		return ApiKeyCreatedResponse{}, err
+	}
+	return ApiKeyCreatedResponse{Id: keyId, Key: key}, nil
+}
+
+// Edit changes a key, with the same limits on its scopes as Insert.
+func (s *ApiKeyService) Edit(claims *security.UserClaims, id int, payload *ApiKeyUpsertPayload) error {
+	expiresAt, err := s.preparePayload(claims, payload)
+	if err != nil {
+		return err
+	}
+	return s.repo.edit(id, payload.Name, payload.Scopes, expiresAt)
+}
+
+func (s *ApiKeyService) Revoke(id int) error {
+	return s.repo.revoke(id)
+}
+
+// Authenticate resolves a key sent by a client into claims granting its scopes, or nil
+// when the key doesn't work.
+func (s *ApiKeyService) Authenticate(key string) (*security.UserClaims, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, nil
+	}
+
+	apiKey, err := s.repo.authenticate(security.HashToken(key))
+	if err != nil || apiKey == nil {
+		return nil, err
+	}
+	return &security.UserClaims{ApiKeyId: apiKey.Id, Permissions: apiKey.Scopes}, nil
+}
+
+// preparePayload checks the scopes against the caller, who has to be a user: a key that could
+// make keys would outlive the user who made it and could hand out its scopes indefinitely.
+func (s *ApiKeyService) preparePayload(
+	claims *security.UserClaims,
+	payload *ApiKeyUpsertPayload,
+) (*time.Time, error) {
+	if claims.ApiKeyId != 0 {
+		return nil, exception.Forbidden("api key", "api keys can't create or edit api keys")
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+
+	unknown, err := s.repo.findUnknownScopes(payload.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) > 0 {
+		return nil, exception.Validation("api key", "unknown scopes: "+strings.Join(unknown, ", "))
+	}
+
+	var notHeld []string
+	for _, scope := range payload.Scopes {
+		if !claims.HasPermission(scope) {
+			notHeld = append(notHeld, scope)
+		}
+	}
+	if len(notHeld) > 0 {
+		return nil, exception.Forbidden("api key", "you can't grant scopes you don't have: "+strings.Join(notHeld, ", "))
+	}
+
+	if payload.ExpiresAt == "" {
+		return nil, nil
+	}
+	expiresAt, err := validator.ParseDateTime(payload.ExpiresAt)
+	if err != nil {
+		return nil, exception.Validation("api key", "expires_at is not valid").WithCause(err)
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, exception.Validation("api key", "expires_at must be in the future")
+	}
+	expiresAt = expiresAt.UTC()
+	return &expiresAt, nil
+}
@@ -0,0 +1,7 @@
+package genre
+
+type GenreSearchResponse struct {
+	Id    int     `json:"id"    db:"id"`
+	Title string  `json:"title" db:"title"`
+	Rank  float32 `json:"rank"  db:"rank"`
+}
@@ -3,61 +3,106 @@ package genre
 import (
 	"context"
 	"errors"
-	"net/http"
-	"strconv"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/mhvn092/movie-go/internal/platform/repository"
 	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type GenreRepository struct {
 	*repository.BaseRepository
 }
 
+// ListPagination lists the fields genres can be sorted by.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":         {Expr: "id", Type: "integer"},
+		"title":      {Expr: "title", Type: "text"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	DefaultSort: "id",
+}
+
 func NewGenreRepository(base *repository.BaseRepository) *GenreRepository {
 	return &GenreRepository{BaseRepository: base}
 }
 
 func (r *GenreRepository) getAllGenresPaginated(
 	params web.PaginationParam,
-) (res []Genre, nextCursor int, err error) {
+) (page web.Page[Genre], err error) {
+	keyset, err := web.NewKeyset(params, ListPagination, 1)
+	if err != nil {
+		return page, err
+	}
+
 	rows, err := r.DB.Query(
 		context.Background(),
-		"select id, title from movie.genre where id >= $1 limit $2",
-		params.CursorID,
-		params.Limit,
+		fmt.Sprintf(
+			"select id, title, %s from movie.genre %s order by %s limit %d",
+			keyset.SelectColumns(),
+			web.WhereClause(keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		keyset.Args()...,
 	)
-
-	defer rows.Close()
-
 	if err != nil {
-		return nil, 0, err
+		return page, err
 	}
 
-	res = []Genre{}
+	defer rows.Close()
+
+	res := []Genre{}
+	rowKeys := [][]string{}
 
 	for rows.Next() {
 		var item Genre
-		err = rows.Scan(
-			&item.Id,
-			&item.Title,
-		)
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(append([]interface{}{&item.Id, &item.Title}, keyDest...)...)
 		if err != nil {
 			return
 		}
 		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
 	}
 
-	if len(res) > 0 {
-		nextCursor = res[len(res)-1].Id + 1
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from movie.genre")
 	}
 
 	return
 }
 
+// getAutocomplete returns the titles matching a partially typed term, best match first.
+// Genres are few and short, so this also serves as their search.
+func (r *GenreRepository) getAutocomplete(searchTerm string, limit int) ([]web.Suggestion, error) {
+	return r.QuerySuggestions(
+		"genre",
+		fmt.Sprintf(
+			`select id, title, %s as score
+    from movie.genre
+    where %s
+    order by score desc, id
+    limit $3`,
+			repository.AutocompleteScore("title"),
+			repository.AutocompleteCondition("title"),
+		),
+		repository.EscapeLike(searchTerm),
+		searchTerm,
+		limit,
+	)
+}
+
 func (r *GenreRepository) checkIfExists(query string, args ...interface{}) (bool, error) {
 	var genreId int
 	err := r.DB.QueryRow(
@@ -86,6 +131,10 @@ func (r *GenreRepository) checkIfExistsByNameExcludingId(id int, title string) (
 	return r.checkIfExists("select id from movie.genre where title = $1 and id <> $2", title, id)
 }
 
+func (r *GenreRepository) findMissingIds(ids []int) ([]int, error) {
+	return r.FindMissingIds("movie.genre", ids)
+}
+
 func (r *GenreRepository) insert(genre *Genre) (int, error) {
 	exists, err := r.checkIfExistsByTitle(genre.Title)
 	if err != nil {
@@ -93,7 +142,7 @@ func (r *GenreRepository) insert(genre *Genre) (int, error) {
 	}
 
 	if exists {
-		return 0, errors.New(strconv.Itoa(http.StatusConflict))
+		return 0, exception.Conflict("genre")
 	}
 
 	var genreId int
@@ -127,7 +176,7 @@ func (r *GenreRepository) edit(id int, genre *Genre) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("genre", id)
 	}
 
 	exists, err = r.checkIfExistsByNameExcludingId(id, genre.Title)
@@ -136,7 +185,7 @@ func (r *GenreRepository) edit(id int, genre *Genre) error {
 	}
 
 	if exists {
-		return errors.New(strconv.Itoa(http.StatusConflict))
+		return exception.Conflict("genre")
 	}
 
 	cmdTag, err := r.DB.Exec(
@@ -164,13 +213,16 @@ func (r *GenreRepository) delete(id int) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("genre", id)
 	}
 	cmdTag, err := r.DB.Exec(
 		context.Background(),
 		"delete from movie.genre where id = $1",
 		id,
 	)
+	if repository.IsForeignKeyViolation(err) {
+		return exception.StillReferenced("genre", id, "movies").WithCause(err)
+	}
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,10 @@
 package genre
 
-import "github.com/mhvn092/movie-go/internal/platform/web"
+import (
+	"strings"
+
+	"github.com/mhvn092/movie-go/internal/platform/web"
+)
 
 type GenreService struct {
 	repo *GenreRepository
@@ -10,10 +14,27 @@ func NewGenreService(repo *GenreRepository) *GenreService {
 	return &GenreService{repo: repo}
 }
 
-func (s *GenreService) GetAllPaginated(p web.PaginationParam) ([]Genre, int, error) {
+func (s *GenreService) GetAllPaginated(p web.PaginationParam) (web.Page[Genre], error) {
 	return s.repo.getAllGenresPaginated(p)
 }
 
+func (s *GenreService) GetSearchResults(term string, limit int) ([]GenreSearchResponse, error) {
+	suggestions, err := s.repo.getAutocomplete(strings.TrimSpace(term), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]GenreSearchResponse, len(suggestions))
+	for i, suggestion := range suggestions {
+		res[i] = GenreSearchResponse{Id: suggestion.Id, Title: suggestion.Label, Rank: suggestion.Score}
+	}
+	return res, nil
+}
+
+func (s *GenreService) Autocomplete(term string, limit int) ([]web.Suggestion, error) {
+	return s.repo.getAutocomplete(strings.TrimSpace(term), limit)
+}
+
 func (s *GenreService) Insert(genre *Genre) (int, error) {
 	return s.repo.insert(genre)
 }
@@ -22,6 +43,10 @@ func (s *GenreService) CheckIfExists(id int) (bool, error) {
 	return s.repo.checkIfExistsById(id)
 }
 
+func (s *GenreService) FindMissingIds(ids []int) ([]int, error) {
+	return s.repo.findMissingIds(ids)
+}
+
 func (s *GenreService) Edit(id int, genre *Genre) error {
 	return s.repo.edit(id, genre)
 }
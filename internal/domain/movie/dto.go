@@ -1,9 +1,13 @@
 package movie
 
+import "github.com/mhvn092/movie-go/internal/platform/web"
+
 type MovieGetAllResponse struct {
-	Id             int    `json:"id"              db:"id"`
-	Title          string `json:"title"           db:"title"`
-	ProductionYear int    `json:"production_year" db:"production_year"`
+	Id             int      `json:"id"              db:"id"`
+	Title          string   `json:"title"           db:"title"`
+	ProductionYear int      `json:"production_year" db:"production_year"`
+	AverageRating  *float64 `json:"average_rating"  db:"average_rating"`
+	VoteCount      int      `json:"vote_count"      db:"vote_count"`
 }
 
 type MovieGetDetailResponse struct {
@@ -12,12 +16,20 @@ type MovieGetDetailResponse struct {
 	ProductionYear int                      `json:"production_year" db:"production_year"`
 	DirectorId     int                      `json:"director_id"     db:"director_id"`
 	DirectorName   string                   `json:"director_name"   db:"director_name"`
-	GenreId        int                      `json:"genre_id"        db:"genre_id"`
-	GenreName      string                   `json:"genre_name"      db:"genre_name"`
+	Genres         []MovieGenreResponse     `json:"genres"          db:"genres"`
 	Description    string                   `json:"description"     db:"description"`
+	AverageRating  *float64                 `json:"average_rating"  db:"average_rating"`
+	VoteCount      int                      `json:"vote_count"      db:"vote_count"`
+	InWatchlist    *bool                    `json:"in_watchlist,omitempty"`
+	Watched        *bool                    `json:"watched,omitempty"`
 	Staffs         []MovieStaffBaseResponse `json:"movie_staffs"    db:"movie_staffs"`
 }
 
+type MovieGenreResponse struct {
+	Id    int    `json:"id"    db:"id"`
+	Title string `json:"title" db:"title"`
+}
+
 type MovieStaffBaseResponse struct {
 	StaffId        int    `json:"staff_id"         db:"staff_id"`
 	StaffName      string `json:"staff_name"       db:"staff_name"`
@@ -36,7 +48,7 @@ type MovieUpsertPayload struct {
 	Title          string                        `json:"title"           db:"title"           validate:"required,is_string"`
 	ProductionYear int                           `json:"production_year" db:"production_year" validate:"required,is_int,is_valid_year"`
 	DirectorId     int                           `json:"director_id"     db:"director_id"     validate:"required,is_int"`
-	GenreId        int                           `json:"genre_id"        db:"genre_id"        validate:"required,is_int"`
+	GenreIds       []int                         `json:"genre_ids"       db:"genre_ids"       validate:"required"`
 	Description    string                        `json:"description"     db:"description"     validate:"required,is_string"`
 	Staffs         []movieStaffUpsertBasePayload `json:"movie_staffs"    db:"movie_staffs"    validate:"required"`
 }
@@ -45,3 +57,35 @@ type movieStaffUpsertBasePayload struct {
 	StaffId     int `json:"staff_id"      db:"staff_id"      validate:"required, is_int"`
 	StaffTypeId int `json:"staff_type_id" db:"staff_type_id" validate:"required, is_int"`
 }
+
+type MovieListFilter struct {
+	GenreId       int    `query:"genre_id"       validate:"omitempty, is_int"`
+	DirectorId    int    `query:"director_id"    validate:"omitempty, is_int"`
+	StaffId       int    `query:"staff_id"       validate:"omitempty, is_int"`
+	YearFrom      int    `query:"year_from"      validate:"omitempty, is_int, is_valid_year"`
+	YearTo        int    `query:"year_to"        validate:"omitempty, is_int, is_valid_year"`
+	CreatedAfter  string `query:"created_after"  validate:"omitempty, is_datetime_string"`
+	CreatedBefore string `query:"created_before" validate:"omitempty, is_datetime_string"`
+	UpdatedAfter  string `query:"updated_after"  validate:"omitempty, is_datetime_string"`
+	UpdatedBefore string `query:"updated_before" validate:"omitempty, is_datetime_string"`
+}
+
+type MovieSearchFilter struct {
+	GenreId int `query:"genre_id" validate:"omitempty, is_int"`
+}
+
+type MovieSearchResponse struct {
+	Id             int     `json:"id"              db:"id"`
+	Title          string  `json:"title"           db:"title"`
+	ProductionYear int     `json:"production_year" db:"production_year"`
+	Rank           float32 `json:"rank"            db:"rank"`
+	Highlight      string  `json:"highlight,omitempty" db:"highlight"`
+}
+
+// MovieSearchPage tells which mode produced the results and, when nothing matched exactly,
+// the closest title.
+type MovieSearchPage struct {
+	web.Page[MovieSearchResponse]
+	Mode       string `json:"mode"`
+	Suggestion string `json:"did_you_mean,omitempty"`
+}
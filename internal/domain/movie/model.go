@@ -6,7 +6,6 @@ type Movie struct {
 	Id             int       `json:"id"              db:"id"`
 	Title          string    `json:"title"           db:"title"`
 	DirectorId     int       `json:"director_id"     db:"director_id"`
-	GenreId        int       `json:"genre_id"        db:"genre_id"`
 	ProductionYear int       `json:"production_year" db:"production_year"`
 	Description    string    `json:"description"     db:"description"`
 	CreatedAt      time.Time `                       db:"created_at"`
@@ -18,3 +17,8 @@ type MovieStaff struct {
 	StaffId     int `json:"staff_id"      db:"staff_id"`
 	StaffTypeId int `json:"staff_type_id" db:"staff_type_id"`
 }
+
+type MovieGenre struct {
+	MovieId int `json:"movie_id" db:"movie_id"`
+	GenreId int `json:"genre_id" db:"genre_id"`
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -13,49 +11,124 @@ import (
 
 	"github.com/mhvn092/movie-go/internal/platform/repository"
 	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type MovieRepository struct {
 	*repository.BaseRepository
 }
 
+// ListPagination lists the fields movies can be sorted by.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":              {Expr: "m.id", Type: "integer"},
+		"title":           {Expr: "m.title", Type: "text"},
+		"production_year": {Expr: "m.production_year", Type: "integer"},
+		"created_at":      {Expr: "m.created_at", Type: "timestamp"},
+		"average_rating":  {Expr: "coalesce(" + averageRatingExpr + ", 0)", Type: "float8"},
+		"vote_count":      {Expr: "m.vote_count", Type: "integer"},
+	},
+	DefaultSort: "id",
+}
+
+// averageRatingExpr reads the average of the ratings from the aggregates that
+// movie.review_rating_trigger keeps on the movie. It is null before the first vote.
+const averageRatingExpr = "round(m.rating_sum::numeric / nullif(m.vote_count, 0), 1)::float8"
+
+// SearchPagination lists the fields movie search results can be sorted by, best match first by default.
+// The rank uses the A (title) and B (description) weights set by movie.movie_search_trigger.
+var SearchPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"rank": {
+			Expr: "ts_rank_cd('{0.1, 0.2, 0.4, 1.0}', m.search_vector, query)",
+			Type: "real",
+		},
+		"id":              {Expr: "m.id", Type: "integer"},
+		"title":           {Expr: "m.title", Type: "text"},
+		"production_year": {Expr: "m.production_year", Type: "integer"},
+	},
+	DefaultSort: "-rank",
+}
+
+// FuzzySearchPagination is SearchPagination for typo tolerant search, ranked by the trigram
+// word similarity of the title.
+var FuzzySearchPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"rank":            {Expr: "word_similarity($1, m.title)", Type: "real"},
+		"id":              {Expr: "m.id", Type: "integer"},
+		"title":           {Expr: "m.title", Type: "text"},
+		"production_year": {Expr: "m.production_year", Type: "integer"},
+	},
+	DefaultSort: "-rank",
+}
+
 func NewMovieRepository(base *repository.BaseRepository) *MovieRepository {
 	return &MovieRepository{BaseRepository: base}
 }
 
 func (r *MovieRepository) getAllMoviePaginated(
 	params web.PaginationParam,
-) (res []MovieGetAllResponse, nextCursor int, err error) {
+	filter MovieListFilter,
+) (page web.Page[MovieGetAllResponse], err error) {
+	conditions, args := getMovieListFilterConditions(filter)
+
+	keyset, err := web.NewKeyset(params, ListPagination, len(args)+1)
+	if err != nil {
+		return page, err
+	}
+
 	rows, err := r.DB.Query(
 		context.Background(),
-		"select id, title, production_year from movie.movie where id >= $1 limit $2",
-		params.CursorID,
-		params.Limit,
+		fmt.Sprintf(
+			"select m.id, m.title, m.production_year, %s, m.vote_count, %s from movie.movie m %s order by %s limit %d",
+			averageRatingExpr,
+			keyset.SelectColumns(),
+			web.WhereClause(append(conditions, keyset.Condition())...),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append(args, keyset.Args()...)...,
 	)
-
-	defer rows.Close()
-
 	if err != nil {
-		return nil, 0, err
+		return page, err
 	}
 
-	res = []MovieGetAllResponse{}
+	defer rows.Close()
+
+	res := []MovieGetAllResponse{}
+	rowKeys := [][]string{}
 
 	for rows.Next() {
 		var item MovieGetAllResponse
+		keys, keyDest := keyset.RowKeys()
 		err = rows.Scan(
-			&item.Id,
-			&item.Title,
-			&item.ProductionYear,
+			append([]interface{}{
+				&item.Id,
+				&item.Title,
+				&item.ProductionYear,
+				&item.AverageRating,
+				&item.VoteCount,
+			}, keyDest...)...,
 		)
 		if err != nil {
 			return
 		}
 		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
 	}
 
-	if len(res) > 0 {
-		nextCursor = res[len(res)-1].Id + 1
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows(
+			"select count(*) from movie.movie m "+web.WhereClause(conditions...),
+			args...,
+		)
 	}
 
 	return
@@ -63,43 +136,175 @@ func (r *MovieRepository) getAllMoviePaginated(
 
 func (r *MovieRepository) getSearchResults(
 	searchTerm string,
-) (res []MovieGetAllResponse, err error) {
-	terms := strings.Fields(searchTerm)
-	queryTerm := strings.Join(terms, " <-> ") + ":*"
+	filter MovieSearchFilter,
+	params web.PaginationParam,
+) (page web.Page[MovieSearchResponse], err error) {
+	conditions, args := getMovieSearchFilterConditions(filter, []interface{}{searchTerm})
+	conditions = append([]string{"m.search_vector @@ query"}, conditions...)
+
+	keyset, err := web.NewKeyset(params, SearchPagination, len(args)+1)
+	if err != nil {
+		return page, err
+	}
+
+	from := "from movie.movie m, to_tsquery('simple', $1) query"
+	where := web.WhereClause(append(conditions, keyset.Condition())...)
+
 	rows, err := r.DB.Query(
 		context.Background(),
-		`SELECT id, title, production_year
-    FROM movie.movie
-    WHERE search_vector @@ to_tsquery('simple', $1)
-    LIMIT 10;`,
-		queryTerm,
+		fmt.Sprintf(
+			`select m.id, m.title, m.production_year, %s, ts_headline('simple', m.description, query, '%s'), %s
+    %s
+    %s
+    order by %s
+    limit %d`,
+			SearchPagination.Columns["rank"].Expr,
+			repository.HeadlineOptions,
+			keyset.SelectColumns(),
+			from,
+			where,
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append(args, keyset.Args()...)...,
 	)
+	if err != nil {
+		return page, err
+	}
 
 	defer rows.Close()
 
+	res := []MovieSearchResponse{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item MovieSearchResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(
+			append([]interface{}{&item.Id, &item.Title, &item.ProductionYear, &item.Rank, &item.Highlight}, keyDest...)...,
+		)
+		if err != nil {
+			return
+		}
+		item.Highlight = repository.Headline(item.Highlight)
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) "+from+" "+web.WhereClause(conditions...), args...)
+	}
+
+	return
+}
+
+func (r *MovieRepository) getFuzzySearchResults(
+	searchTerm string,
+	filter MovieSearchFilter,
+	params web.PaginationParam,
+) (page web.Page[MovieSearchResponse], err error) {
+	conditions, args := getMovieSearchFilterConditions(filter, []interface{}{searchTerm})
+	conditions = append([]string{"$1 <% m.title"}, conditions...)
+
+	keyset, err := web.NewKeyset(params, FuzzySearchPagination, len(args)+1)
 	if err != nil {
-		fmt.Println("the error is the in the query itself")
-		return nil, err
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf(
+			`select m.id, m.title, m.production_year, %s, %s
+    from movie.movie m
+    %s
+    order by %s
+    limit %d`,
+			FuzzySearchPagination.Columns["rank"].Expr,
+			keyset.SelectColumns(),
+			web.WhereClause(append(conditions, keyset.Condition())...),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append(args, keyset.Args()...)...,
+	)
+	if err != nil {
+		return page, err
 	}
 
-	res = []MovieGetAllResponse{}
+	defer rows.Close()
+
+	res := []MovieSearchResponse{}
+	rowKeys := [][]string{}
 
 	for rows.Next() {
-		var item MovieGetAllResponse
-		err = rows.Scan(
-			&item.Id,
-			&item.Title,
-			&item.ProductionYear,
-		)
+		var item MovieSearchResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(append([]interface{}{&item.Id, &item.Title, &item.ProductionYear, &item.Rank}, keyDest...)...)
 		if err != nil {
 			return
 		}
 		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from movie.movie m "+web.WhereClause(conditions...), args...)
 	}
 
 	return
 }
 
+// getSearchSuggestion returns the title closest to the term, for "did you mean".
+func (r *MovieRepository) getSearchSuggestion(searchTerm string) (string, error) {
+	var title string
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select title from movie.movie
+    where $1 <% title
+    order by word_similarity($1, title) desc, id
+    limit 1`,
+		searchTerm,
+	).Scan(&title)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return title, nil
+}
+
+// getAutocomplete returns the titles matching a partially typed term, best match first.
+func (r *MovieRepository) getAutocomplete(searchTerm string, limit int) ([]web.Suggestion, error) {
+	return r.QuerySuggestions(
+		"movie",
+		fmt.Sprintf(
+			`select id, title, %s as score
+    from movie.movie
+    where %s
+    order by score desc, id
+    limit $3`,
+			repository.AutocompleteScore("title"),
+			repository.AutocompleteCondition("title"),
+		),
+		repository.EscapeLike(searchTerm),
+		searchTerm,
+		limit,
+	)
+}
+
 func (r *MovieRepository) checkIfExists(id int) (bool, error) {
 	var staffId int
 	err := r.DB.QueryRow(
@@ -125,16 +330,15 @@ func (r *MovieRepository) getDetail(id int) (MovieGetDetailResponse, error) {
             m.production_year,
             m.director_id,
             CONCAT(s.first_name, ' ', s.last_name) AS director_name, 
-            m.genre_id,
-            g.title AS genre_name,
             m.description,
+            ` + averageRatingExpr + ` AS average_rating,
+            m.vote_count,
             staff_data.staff_id,
             staff_data.staff_name,
             staff_data.staff_type_id,
             staff_data.staff_type_title
         FROM movie.movie m
         JOIN staff.staff s ON m.director_id = s.id
-        JOIN movie.genre g ON m.genre_id = g.id
         LEFT JOIN LATERAL (
             SELECT 
                 ms.staff_id AS staff_id,
@@ -157,7 +361,7 @@ func (r *MovieRepository) getDetail(id int) (MovieGetDetailResponse, error) {
 
 	if !rows.Next() {
 		if errors.Is(rows.Err(), pgx.ErrNoRows) || rows.Err() == nil {
-			return movie, errors.New(strconv.Itoa(http.StatusNotFound))
+			return movie, exception.NotFound("movie", id)
 		}
 		return movie, fmt.Errorf("failed to get first row: %w", rows.Err())
 	}
@@ -169,9 +373,9 @@ func (r *MovieRepository) getDetail(id int) (MovieGetDetailResponse, error) {
 		&movie.ProductionYear,
 		&movie.DirectorId,
 		&movie.DirectorName,
-		&movie.GenreId,
-		&movie.GenreName,
 		&movie.Description,
+		&movie.AverageRating,
+		&movie.VoteCount,
 		&staff.StaffId,
 		&staff.StaffName,
 		&staff.StaffTypeId,
@@ -197,9 +401,9 @@ func (r *MovieRepository) getDetail(id int) (MovieGetDetailResponse, error) {
 			new(int),
 			new(int),
 			new(string),
-			new(int),
-			new(string),
 			new(string),
+			new(*float64),
+			new(int),
 			&staff.StaffId,
 			&staff.StaffName,
 			&staff.StaffTypeId,
@@ -223,9 +427,52 @@ func (r *MovieRepository) getDetail(id int) (MovieGetDetailResponse, error) {
 		return movie, fmt.Errorf("error after iterating through rows: %w", err)
 	}
 
+	movie.Genres, err = r.getMovieGenres(id)
+	if err != nil {
+		return movie, fmt.Errorf("failed to query movie genres: %w", err)
+	}
+
 	return movie, nil
 }
 
+// getUserMovieState tells whether the movie is on the user's watchlist and whether they watched it.
+func (r *MovieRepository) getUserMovieState(userId int, movieId int) (inWatchlist bool, watched bool, err error) {
+	err = r.DB.QueryRow(
+		context.Background(),
+		`select
+    exists (select 1 from person.watchlist where user_id = $1 and movie_id = $2),
+    exists (select 1 from person.watched where user_id = $1 and movie_id = $2)`,
+		userId,
+		movieId,
+	).Scan(&inWatchlist, &watched)
+	return
+}
+
+func (r *MovieRepository) getMovieGenres(movieId int) ([]MovieGenreResponse, error) {
+	rows, err := r.DB.Query(
+		context.Background(),
+		`select g.id, g.title from movie.movie_genre mg
+    join movie.genre g on g.id = mg.genre_id
+    where mg.movie_id = $1
+    order by g.title`,
+		movieId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []MovieGenreResponse{}
+	for rows.Next() {
+		var genre MovieGenreResponse
+		if err := rows.Scan(&genre.Id, &genre.Title); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	return genres, rows.Err()
+}
+
 func (r *MovieRepository) insert(payload *MovieUpsertPayload) (movieId int, err error) {
 	ctx := context.Background()
 	tx, err := r.DB.Begin(ctx)
@@ -247,17 +494,21 @@ func (r *MovieRepository) insert(payload *MovieUpsertPayload) (movieId int, err
 
 	err = tx.QueryRow(
 		ctx,
-		"INSERT INTO movie.movie (title, description, production_year, director_id, genre_id) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		"INSERT INTO movie.movie (title, description, production_year, director_id) VALUES ($1, $2, $3, $4) RETURNING id",
 		payload.Title,
 		payload.Description,
 		payload.ProductionYear,
 		payload.DirectorId,
-		payload.GenreId,
 	).Scan(&movieId)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert movie: %w", err)
 	}
 
+	_, err = tx.Exec(ctx, movieGenreInsertQuery, movieId, payload.GenreIds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert movie genres: %w", err)
+	}
+
 	staffQuery, args := getMovieStaffInsertQuery(movieId, payload)
 
 	_, err = tx.Exec(ctx, staffQuery, args...)
@@ -289,12 +540,11 @@ func (r *MovieRepository) edit(id int, payload *MovieUpsertPayload) (err error)
 
 	cmdTag, err := tx.Exec(
 		ctx,
-		"UPDATE movie.movie SET title = $1, description = $2, production_year = $3, director_id = $4, genre_id = $5, updated_at = $6 WHERE id = $7",
+		"UPDATE movie.movie SET title = $1, description = $2, production_year = $3, director_id = $4, updated_at = $5 WHERE id = $6",
 		payload.Title,
 		payload.Description,
 		payload.ProductionYear,
 		payload.DirectorId,
-		payload.GenreId,
 		time.Now(),
 		id,
 	)
@@ -303,7 +553,17 @@ func (r *MovieRepository) edit(id int, payload *MovieUpsertPayload) (err error)
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("no movie found with id %d", id)
+		return exception.NotFound("movie", id)
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM movie.movie_genre WHERE movie_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete existing movie genres: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, movieGenreInsertQuery, id, payload.GenreIds)
+	if err != nil {
+		return fmt.Errorf("failed to insert movie genres: %w", err)
 	}
 
 	_, err = tx.Exec(ctx, "DELETE FROM movie.movie_staff WHERE movie_id = $1", id)
@@ -344,6 +604,11 @@ func (r *MovieRepository) delete(id int) (err error) {
 		return err
 	}
 
+	_, err = tx.Exec(ctx, "delete from movie.movie_genre where movie_id = $1", id)
+	if err != nil {
+		return err
+	}
+
 	_, err = tx.Exec(ctx, "delete from movie.movie where id = $1", id)
 	if err != nil {
 		return err
@@ -352,6 +617,83 @@ func (r *MovieRepository) delete(id int) (err error) {
 	return nil
 }
 
+// movieGenreInsertQuery links the movie ($1) to every genre in the $2 array, ignoring repeats.
+const movieGenreInsertQuery = `INSERT INTO movie.movie_genre (movie_id, genre_id)
+    SELECT $1, genre_id FROM unnest($2::integer[]) AS genre_id
+    ON CONFLICT DO NOTHING`
+
+// movieHasGenreCondition matches movies of movie.movie m tagged with the genre in placeholder.
+func movieHasGenreCondition(placeholder string) string {
+	return "EXISTS (select 1 from movie.movie_genre mg where mg.movie_id = m.id and mg.genre_id = " +
+		placeholder + ")"
+}
+
+// getMovieSearchFilterConditions turns the search filters into conditions on movie.movie m,
+// adding their arguments after args.
+func getMovieSearchFilterConditions(
+	filter MovieSearchFilter,
+	args []interface{},
+) ([]string, []interface{}) {
+	var conditions []string
+	if filter.GenreId != 0 {
+		args = append(args, filter.GenreId)
+		conditions = append(conditions, movieHasGenreCondition(fmt.Sprintf("$%d", len(args))))
+	}
+	return conditions, args
+}
+
+// getMovieListFilterConditions turns the listing filters into conditions on movie.movie m,
+// numbering placeholders from $1.
+func getMovieListFilterConditions(filter MovieListFilter) (conditions []string, args []interface{}) {
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(
+			conditions,
+			strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))),
+		)
+	}
+
+	if filter.GenreId != 0 {
+		add(movieHasGenreCondition("$?"), filter.GenreId)
+	}
+	if filter.DirectorId != 0 {
+		add("m.director_id = $?", filter.DirectorId)
+	}
+	if filter.StaffId != 0 {
+		add(
+			"(m.director_id = $? OR EXISTS (select 1 from movie.movie_staff ms "+
+				"where ms.movie_id = m.id and ms.staff_id = $?))",
+			filter.StaffId,
+		)
+	}
+	if filter.YearFrom != 0 {
+		add("m.production_year >= $?", filter.YearFrom)
+	}
+	if filter.YearTo != 0 {
+		add("m.production_year <= $?", filter.YearTo)
+	}
+
+	timeWindows := []struct {
+		value     string
+		condition string
+	}{
+		{filter.CreatedAfter, "m.created_at >= $?"},
+		{filter.CreatedBefore, "m.created_at < $?"},
+		{filter.UpdatedAfter, "m.updated_at >= $?"},
+		{filter.UpdatedBefore, "m.updated_at < $?"},
+	}
+	for _, window := range timeWindows {
+		if window.value == "" {
+			continue
+		}
+		if t, err := validator.ParseDateTime(window.value); err == nil {
+			add(window.condition, t.UTC())
+		}
+	}
+
+	return conditions, args
+}
+
 func getMovieStaffInsertQuery(
 	id int,
 	payload *MovieUpsertPayload,
@@ -1,15 +1,17 @@
 package movie
 
 import (
-	"errors"
+	"strings"
+
 	"fmt"
-	"net/http"
-	"strconv"
 
 	"github.com/mhvn092/movie-go/internal/domain/genre"
 	"github.com/mhvn092/movie-go/internal/domain/staff"
 	stafftype "github.com/mhvn092/movie-go/internal/domain/staff-type"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
 	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type MovieService struct {
@@ -33,12 +35,67 @@ func NewMovieService(
 	}
 }
 
-func (s *MovieService) GetAllPaginated(p web.PaginationParam) ([]MovieGetAllResponse, int, error) {
-	return s.repo.getAllMoviePaginated(p)
+func (s *MovieService) GetAllPaginated(
+	p web.PaginationParam,
+	filter MovieListFilter,
+) (web.Page[MovieGetAllResponse], error) {
+	if err := validateListFilter(filter); err != nil {
+		return web.Page[MovieGetAllResponse]{}, err
+	}
+	return s.repo.getAllMoviePaginated(p, filter)
 }
 
-func (s *MovieService) GetSearchResults(searchTerm string) ([]MovieGetAllResponse, error) {
-	return s.repo.getSearchResults(searchTerm)
+// GetSearchResults runs a full text search, or a trigram search in fuzzy mode. When the first
+// page of a full text search is empty it suggests the closest match, and in auto mode
+// returns the fuzzy results instead.
+func (s *MovieService) GetSearchResults(
+	search web.SearchQuery,
+	filter MovieSearchFilter,
+	p web.PaginationParam,
+) (result MovieSearchPage, err error) {
+	searchTerm := strings.TrimSpace(search.Term)
+
+	mode, err := search.PageMode("movie", p)
+	if err != nil {
+		return result, err
+	}
+
+	if mode == web.SearchModeFuzzy {
+		result.Mode = web.SearchModeFuzzy
+		p.Mode = web.SearchModeFuzzy
+		result.Page, err = s.repo.getFuzzySearchResults(searchTerm, filter, p)
+		return
+	}
+
+	query := repository.ToPrefixTsQuery(searchTerm)
+	if query == "" {
+		return result, exception.InvalidFields(
+			"movie",
+			"Invalid parameter",
+			[]exception.FieldError{{Field: "term", Message: "must contain a letter or a digit"}},
+		)
+	}
+
+	result.Mode = web.SearchModeExact
+	p.Mode = web.SearchModeExact
+	result.Page, err = s.repo.getSearchResults(query, filter, p)
+	if err != nil || p.Cursor != nil || len(result.Items) > 0 {
+		return
+	}
+
+	result.Suggestion, err = s.repo.getSearchSuggestion(searchTerm)
+	if err != nil || mode == web.SearchModeExact {
+		return
+	}
+
+	result.Mode = web.SearchModeFuzzy
+	p.Mode = web.SearchModeFuzzy
+	result.Page, err = s.repo.getFuzzySearchResults(searchTerm, filter, p)
+	return
+}
+
+func (s *MovieService) Autocomplete(term string, limit int) ([]web.Suggestion, error) {
+	return s.repo.getAutocomplete(strings.TrimSpace(term), limit)
 }
 
 func (s *MovieService) Insert(payload *MovieUpsertPayload) (int, error) {
@@ -49,8 +106,21 @@ func (s *MovieService) Insert(payload *MovieUpsertPayload) (int, error) {
 	return s.repo.insert(payload)
 }
 
-func (s *MovieService) GetDetail(id int) (MovieGetDetailResponse, error) {
-	return s.repo.getDetail(id)
+// GetDetail returns the movie. For a signed in user (userId other than 0) it also tells
+// whether the movie is on their watchlist and whether they watched it.
+func (s *MovieService) GetDetail(id int, userId int) (MovieGetDetailResponse, error) {
+	movie, err := s.repo.getDetail(id)
+	if err != nil || userId == 0 {
+		return movie, err
+	}
+
+	inWatchlist, watched, err := s.repo.getUserMovieState(userId, id)
+	if err != nil {
+		return movie, err
+	}
+	movie.InWatchlist = &inWatchlist
+	movie.Watched = &watched
+	return movie, nil
 }
 
 func (s *MovieService) Edit(id int, payload *MovieUpsertPayload) error {
@@ -60,7 +130,7 @@ func (s *MovieService) Edit(id int, payload *MovieUpsertPayload) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("movie", id)
 	}
 
 	if err = s.validateUpsertPayload(payload); err != nil {
@@ -76,28 +146,28 @@ func (s *MovieService) Delete(id int) error {
 		return err
 	}
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("movie", id)
 	}
 	return s.repo.delete(id)
 }
 
 func (s *MovieService) validateUpsertPayload(payload *MovieUpsertPayload) error {
-	exists, err := s.genreService.CheckIfExists(payload.GenreId)
-	if err != nil {
-		return err
+	genreIds := make(map[int]bool)
+	for _, id := range payload.GenreIds {
+		genreIds[id] = true
 	}
 
-	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+	if err := s.validateUpsertPayloadIds(toSlice(genreIds), s.genreService.FindMissingIds, "genre"); err != nil {
+		return err
 	}
 
 	staffIds, staffTypeIds := collectUpsertUniqueIds(payload)
 
-	if err := s.validateUpsertPayloadIds(staffIds, s.staffService.CheckCountOfExistingIds, "staff"); err != nil {
+	if err := s.validateUpsertPayloadIds(staffIds, s.staffService.FindMissingIds, "staff"); err != nil {
 		return err
 	}
 
-	if err := s.validateUpsertPayloadIds(staffTypeIds, s.staffTypeService.CheckCountOfExistingIds, "staff type"); err != nil {
+	if err := s.validateUpsertPayloadIds(staffTypeIds, s.staffTypeService.FindMissingIds, "staff type"); err != nil {
 		return err
 	}
 
@@ -106,18 +176,18 @@ func (s *MovieService) validateUpsertPayload(payload *MovieUpsertPayload) error
 
 func (s *MovieService) validateUpsertPayloadIds(
 	ids []int,
-	checkFunc func([]int) (bool, error),
+	findMissingFunc func([]int) ([]int, error),
 	resource string,
 ) error {
 	if len(ids) == 0 {
 		return nil
 	}
-	exists, err := checkFunc(ids)
+	missing, err := findMissingFunc(ids)
 	if err != nil {
 		return fmt.Errorf("failed to check %s IDs: %w", resource, err)
 	}
-	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+	if len(missing) > 0 {
+		return exception.NotFound(resource, missing...)
 	}
 	return nil
 }
@@ -143,3 +213,38 @@ func toSlice(set map[int]bool) []int {
 	}
 	return result
 }
+
+// validateListFilter rejects ranges whose lower bound comes after the upper bound.
+func validateListFilter(filter MovieListFilter) error {
+	var fields []exception.FieldError
+
+	if filter.YearFrom != 0 && filter.YearTo != 0 && filter.YearFrom > filter.YearTo {
+		fields = append(fields, exception.FieldError{
+			Field:   "year_from",
+			Message: "must not be after year_to",
+		})
+	}
+
+	windows := []struct{ field, from, to string }{
+		{"created_after", filter.CreatedAfter, filter.CreatedBefore},
+		{"updated_after", filter.UpdatedAfter, filter.UpdatedBefore},
+	}
+	for _, window := range windows {
+		if window.from == "" || window.to == "" {
+			continue
+		}
+		from, fromErr := validator.ParseDateTime(window.from)
+		to, toErr := validator.ParseDateTime(window.to)
+		if fromErr == nil && toErr == nil && !from.Before(to) {
+			fields = append(fields, exception.FieldError{
+				Field:   window.field,
+				Message: "must be before the end of the window",
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		return exception.InvalidFields("movie", "Invalid parameter", fields)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package review
+
+import "time"
+
+type ReviewCreatePayload struct {
+	MovieId int    `json:"movie_id" db:"movie_id" validate:"required, is_int"`
+	Rating  int    `json:"rating"   db:"rating"   validate:"required, is_int, min=1, max=10"`
+	Body    string `json:"body"     db:"body"     validate:"omitempty, is_string, max_len=5000"`
+}
+
+type ReviewEditPayload struct {
+	Rating int    `json:"rating" db:"rating" validate:"required, is_int, min=1, max=10"`
+	Body   string `json:"body"   db:"body"   validate:"omitempty, is_string, max_len=5000"`
+}
+
+type ReviewResponse struct {
+	Id        int        `json:"id"                   db:"id"`
+	MovieId   int        `json:"movie_id"             db:"movie_id"`
+	UserId    int        `json:"user_id"              db:"user_id"`
+	UserName  string     `json:"user_name"            db:"user_name"`
+	Rating    int        `json:"rating"               db:"rating"`
+	Body      string     `json:"body,omitempty"       db:"body"`
+	CreatedAt time.Time  `json:"created_at"           db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
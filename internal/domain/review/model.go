@@ -0,0 +1,13 @@
+package review
+
+import "time"
+
+type Review struct {
+	Id        int       `json:"id"       db:"id"`
+	MovieId   int       `json:"movie_id" db:"movie_id"`
+	UserId    int       `json:"user_id"  db:"user_id"`
+	Rating    int       `json:"rating"   db:"rating"`
+	Body      string    `json:"body"     db:"body"`
+	CreatedAt time.Time `                db:"created_at"`
+	UpdatedAt time.Time `                db:"updated_at"`
+}
@@ -0,0 +1,185 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type ReviewRepository struct {
+	*repository.BaseRepository
+}
+
+// ListPagination lists the fields the reviews of a movie can be sorted by, newest first by default.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":         {Expr: "r.id", Type: "integer"},
+		"rating":     {Expr: "r.rating", Type: "smallint"},
+		"created_at": {Expr: "r.created_at", Type: "timestamp"},
+	},
+	DefaultSort: "-created_at",
+}
+
+func NewReviewRepository(base *repository.BaseRepository) *ReviewRepository {
+	return &ReviewRepository{BaseRepository: base}
+}
+
+func (r *ReviewRepository) getMovieReviewsPaginated(
+	movieId int,
+	params web.PaginationParam,
+) (page web.Page[ReviewResponse], err error) {
+	keyset, err := web.NewKeyset(params, ListPagination, 2)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf(
+			`select r.id, r.movie_id, r.user_id, concat(u.first_name, ' ', u.last_name),
+    r.rating, coalesce(r.body, ''), r.created_at, r.updated_at, %s
+    from movie.review r
+    join person.users u on u.id = r.user_id
+    %s
+    order by %s
+    limit %d`,
+			keyset.SelectColumns(),
+			web.WhereClause("r.movie_id = $1", keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append([]interface{}{movieId}, keyset.Args()...)...,
+	)
+	if err != nil {
+		return page, err
+	}
+
+	defer rows.Close()
+
+	res := []ReviewResponse{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item ReviewResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(
+			append([]interface{}{
+				&item.Id,
+				&item.MovieId,
+				&item.UserId,
+				&item.UserName,
+				&item.Rating,
+				&item.Body,
+				&item.CreatedAt,
+				&item.UpdatedAt,
+			}, keyDest...)...,
+		)
+		if err != nil {
+			return
+		}
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from movie.review r where r.movie_id = $1", movieId)
+	}
+
+	return
+}
+
+func (r *ReviewRepository) checkIfMovieExists(movieId int) (bool, error) {
+	missing, err := r.FindMissingIds("movie.movie", []int{movieId})
+	if err != nil {
+		return false, err
+	}
+	return len(missing) == 0, nil
+}
+
+func (r *ReviewRepository) getAuthorId(id int) (int, error) {
+	var userId int
+	err := r.DB.QueryRow(
+		context.Background(),
+		"select user_id from movie.review where id = $1",
+		id,
+	).Scan(&userId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, exception.NotFound("review", id)
+		}
+		return 0, err
+	}
+	return userId, nil
+}
+
+func (r *ReviewRepository) insert(userId int, payload *ReviewCreatePayload) (int, error) {
+	var reviewId int
+	err := r.DB.QueryRow(
+		context.Background(),
+		"insert into movie.review (movie_id, user_id, rating, body) values ($1, $2, $3, nullif($4, '')) returning id",
+		payload.MovieId,
+		userId,
+		payload.Rating,
+		payload.Body,
+	).Scan(&reviewId)
+	if repository.IsUniqueViolation(err) {
+		return 0, exception.Conflict("review").WithCause(err)
+	}
+	if repository.IsForeignKeyViolation(err) {
+		return 0, exception.NotFound("movie", payload.MovieId).WithCause(err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return reviewId, nil
+}
+
+func (r *ReviewRepository) edit(id int, payload *ReviewEditPayload) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"update movie.review set rating = $1, body = nullif($2, ''), updated_at = $3 where id = $4",
+		payload.Rating,
+		payload.Body,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return errors.New("Could not update")
+	}
+
+	return nil
+}
+
+func (r *ReviewRepository) delete(id int) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"delete from movie.review where id = $1",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return errors.New("Could not delete")
+	}
+
+	return nil
+}
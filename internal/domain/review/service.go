@@ -0,0 +1,63 @@
+package review
+
+import (
+	"strings"
+
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type ReviewService struct {
+	repo *ReviewRepository
+}
+
+func NewReviewService(repo *ReviewRepository) *ReviewService {
+	return &ReviewService{repo: repo}
+}
+
+func (s *ReviewService) GetMovieReviewsPaginated(
+	movieId int,
+	p web.PaginationParam,
+) (web.Page[ReviewResponse], error) {
+	exists, err := s.repo.checkIfMovieExists(movieId)
+	if err != nil {
+		return web.Page[ReviewResponse]{}, err
+	}
+	if !exists {
+		return web.Page[ReviewResponse]{}, exception.NotFound("movie", movieId)
+	}
+	return s.repo.getMovieReviewsPaginated(movieId, p)
+}
+
+// Insert adds the rating of userId to a movie. Every user can review a movie once.
+func (s *ReviewService) Insert(userId int, payload *ReviewCreatePayload) (int, error) {
+	payload.Body = strings.TrimSpace(payload.Body)
+	return s.repo.insert(userId, payload)
+}
+
+// Edit changes a review, which only its author may do.
+func (s *ReviewService) Edit(userId int, id int, payload *ReviewEditPayload) error {
+	authorId, err := s.repo.getAuthorId(id)
+	if err != nil {
+		return err
+	}
+	if authorId != userId {
+		return exception.Forbidden("review", "only the author can edit a review")
+	}
+
+	payload.Body = strings.TrimSpace(payload.Body)
+	return s.repo.edit(id, payload)
+}
+
+// Delete removes a review on behalf of its author, or of a moderator.
+func (s *ReviewService) Delete(userId int, canModerate bool, id int) error {
+	authorId, err := s.repo.getAuthorId(id)
+	if err != nil {
+		return err
+	}
+	if authorId != userId && !canModerate {
+		return exception.Forbidden("review", "only the author can delete a review")
+	}
+
+	return s.repo.delete(id)
+}
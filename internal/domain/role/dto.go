@@ -0,0 +1,7 @@
+package role
+
+type RoleUpsertPayload struct {
+	Name        string   `json:"name"        validate:"required, is_string, max_len=32"`
+	Description string   `json:"description" validate:"is_string, max_len=255"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,20 @@
+package role
+
+// Built in roles. Admin always holds every permission and normal is given to everyone
+// who signs up, so neither can be renamed or deleted.
+const (
+	Admin  = "admin"
+	Normal = "normal"
+)
+
+type Role struct {
+	Id          int      `json:"id"          db:"id"`
+	Name        string   `json:"name"        db:"name"`
+	Description string   `json:"description" db:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type Permission struct {
+	Name        string `json:"name"        db:"name"`
+	Description string `json:"description" db:"description"`
+}
@@ -0,0 +1,180 @@
+package role
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type RoleRepository struct {
+	*repository.BaseRepository
+}
+
+func NewRoleRepository(base *repository.BaseRepository) *RoleRepository {
+	return &RoleRepository{BaseRepository: base}
+}
+
+func (r *RoleRepository) getAll() ([]Role, error) {
+	rows, err := r.DB.Query(
+		context.Background(),
+		`select ro.id, ro.name, coalesce(ro.description, ''),
+    array(select permission from person.role_permission where role_id = ro.id order by permission)
+    from person.role ro
+    order by ro.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := []Role{}
+	for rows.Next() {
+		var item Role
+		if err := rows.Scan(&item.Id, &item.Name, &item.Description, &item.Permissions); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (r *RoleRepository) getPermissions() ([]Permission, error) {
+	rows, err := r.DB.Query(
+		context.Background(),
+		"select name, coalesce(description, '') from person.permission order by name",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := []Permission{}
+	for rows.Next() {
+		var item Permission
+		if err := rows.Scan(&item.Name, &item.Description); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (r *RoleRepository) getName(id int) (string, error) {
+	var name string
+	err := r.DB.QueryRow(context.Background(), "select name from person.role where id = $1", id).Scan(&name)
+	if err == pgx.ErrNoRows {
+		return "", exception.NotFound("role", id)
+	}
+	return name, err
+}
+
+func (r *RoleRepository) insert(payload *RoleUpsertPayload) (roleId int, err error) {
+	err = r.InTransaction(func(tx pgx.Tx) error {
+		err := tx.QueryRow(
+			context.Background(),
+			"insert into person.role (name, description) values ($1, nullif($2, '')) returning id",
+			payload.Name,
+			payload.Description,
+		).Scan(&roleId)
+		if err != nil {
+			return err
+		}
+		return setPermissions(tx, roleId, payload.Permissions)
+	})
+	return roleId, mapRoleError(err)
+}
+
+// edit renames the role, which the users holding it follow, and replaces its permissions.
+// Access tokens carry the role and its permissions, so when it is renamed or loses a
+// permission the sessions of its users are ended for the change to apply right away.
+func (r *RoleRepository) edit(id int, payload *RoleUpsertPayload) error {
+	err := r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		var name string
+		err := tx.QueryRow(ctx, "select name from person.role where id = $1 for update", id).Scan(&name)
+		if err == pgx.ErrNoRows {
+			return exception.NotFound("role", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.role set name = $1, description = nullif($2, '') where id = $3",
+			payload.Name,
+			payload.Description,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		cmdTag, err := tx.Exec(
+			ctx,
+			"delete from person.role_permission where role_id = $1 and permission <> all($2::varchar[])",
+			id,
+			payload.Permissions,
+		)
+		if err != nil {
+			return err
+		}
+		if err := setPermissions(tx, id, payload.Permissions); err != nil {
+			return err
+		}
+
+		if name == payload.Name && cmdTag.RowsAffected() == 0 {
+			return nil
+		}
+		_, err = tx.Exec(
+			ctx,
+			`update person.session set revoked_at = current_timestamp
+    where revoked_at is null and user_id in (select id from person.users where role = $1)`,
+			payload.Name,
+		)
+		return err
+	})
+	return mapRoleError(err)
+}
+
+func (r *RoleRepository) delete(id int) error {
+	cmdTag, err := r.DB.Exec(context.Background(), "delete from person.role where id = $1", id)
+	if repository.IsForeignKeyViolation(err) {
+		return exception.StillReferenced("role", id, "users").WithCause(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("role", id)
+	}
+
+	return nil
+}
+
+func setPermissions(tx pgx.Tx, roleId int, permissions []string) error {
+	_, err := tx.Exec(
+		context.Background(),
+		"insert into person.role_permission (role_id, permission) select $1, unnest($2::varchar[]) on conflict do nothing",
+		roleId,
+		permissions,
+	)
+	return err
+}
+
+func mapRoleError(err error) error {
+	if repository.IsUniqueViolation(err) {
+		return exception.Conflict("role").WithCause(err)
+	}
+	if repository.IsForeignKeyViolation(err) {
+		return exception.Validation("role", "permissions has an unknown permission").WithCause(err)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package role
+
+import (
+	"strings"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type RoleService struct {
+	repo *RoleRepository
+}
+
+func NewRoleService(repo *RoleRepository) *RoleService {
+	return &RoleService{repo: repo}
+}
+
+func (s *RoleService) GetAll() ([]Role, error) {
+	return s.repo.getAll()
+}
+
+func (s *RoleService) GetPermissions() ([]Permission, error) {
+	return s.repo.getPermissions()
+}
+
+// Insert creates a role. Callers can only grant permissions they have themselves.
+func (s *RoleService) Insert(claims *security.UserClaims, payload *RoleUpsertPayload) (int, error) {
+	if err := preparePayload(claims, payload); err != nil {
+		return 0, err
+	}
+	return s.repo.insert(payload)
+}
+
+// Edit changes a role, with the same limit on its permissions as Insert. Users holding it get
+// added permissions with their next token, and are logged out when it is renamed or loses a
+// permission.
+func (s *RoleService) Edit(claims *security.UserClaims, id int, payload *RoleUpsertPayload) error {
+	if err := preparePayload(claims, payload); err != nil {
+		return err
+	}
+
+	name, err := s.repo.getName(id)
+	if err != nil {
+		return err
+	}
+
+	if name == Admin {
+		return exception.Forbidden("role", "the admin role always has every permission")
+	}
+	if name == Normal && payload.Name != Normal {
+		return exception.Forbidden("role", "the normal role can't be renamed")
+	}
+
+	return s.repo.edit(id, payload)
+}
+
+func (s *RoleService) Delete(id int) error {
+	name, err := s.repo.getName(id)
+	if err != nil {
+		return err
+	}
+
+	if name == Admin || name == Normal {
+		return exception.Forbidden("role", "built in roles can't be deleted")
+	}
+
+	return s.repo.delete(id)
+}
+
+// preparePayload normalizes the payload and refuses permissions the caller doesn't have,
+// which they could otherwise hand to a role and then to themselves. Leaving permissions out
+// means none, as the role's permissions are replaced with them.
+func preparePayload(claims *security.UserClaims, payload *RoleUpsertPayload) error {
+	payload.Name = strings.ToLower(strings.TrimSpace(payload.Name))
+	payload.Description = strings.TrimSpace(payload.Description)
+	if payload.Permissions == nil {
+		payload.Permissions = []string{}
+	}
+
+	var notHeld []string
+	for _, permission := range payload.Permissions {
+		if !claims.HasPermission(permission) {
+			notHeld = append(notHeld, permission)
+		}
+	}
+	if len(notHeld) > 0 {
+		return exception.Forbidden("role", "you can't grant permissions you don't have: "+strings.Join(notHeld, ", "))
+	}
+	return nil
+}
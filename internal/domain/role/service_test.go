@@ -0,0 +1,66 @@
+package role
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func TestPreparePayload(t *testing.T) {
+	editor := &security.UserClaims{Id: 2, Role: "editor", Permissions: []string{"movie:create", "movie:update", "role:manage"}}
+
+	tests := []struct {
+		name        string
+		permissions []string
+		want        []string
+		forbidden   bool
+	}{
+		{name: "permissions the caller has", permissions: []string{"movie:create"}, want: []string{"movie:create"}},
+		{name: "no permissions", permissions: []string{}, want: []string{}},
+		{name: "permissions left out", permissions: nil, want: []string{}},
+		{name: "permission the caller lacks", permissions: []string{"movie:create", "user:manage"}, forbidden: true},
+		{name: "api key permission", permissions: []string{"api-key:manage"}, forbidden: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &RoleUpsertPayload{Name: " Curator ", Permissions: tt.permissions}
+			err := preparePayload(editor, payload)
+
+			if tt.forbidden {
+				if !errors.Is(err, exception.ErrForbidden) {
+					t.Fatalf("got error %v, want forbidden", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if payload.Name != "curator" {
+				t.Errorf("got name %q", payload.Name)
+			}
+			if !reflect.DeepEqual(payload.Permissions, tt.want) {
+				t.Errorf("got permissions %#v, want %#v", payload.Permissions, tt.want)
+			}
+		})
+	}
+}
+
+// The permissions are checked before the role is looked up, so these never reach the database.
+func TestInsertAndEditRefusePermissionsTheCallerLacks(t *testing.T) {
+	s := &RoleService{}
+	editor := &security.UserClaims{Id: 2, Permissions: []string{"role:manage"}}
+
+	_, err := s.Insert(editor, &RoleUpsertPayload{Name: "owner", Permissions: []string{"user:manage"}})
+	if !errors.Is(err, exception.ErrForbidden) {
+		t.Errorf("insert: got error %v, want forbidden", err)
+	}
+
+	err = s.Edit(editor, 3, &RoleUpsertPayload{Name: "editor", Permissions: []string{"role:manage", "user:manage"}})
+	if !errors.Is(err, exception.ErrForbidden) {
+		t.Errorf("edit: got error %v, want forbidden", err)
+	}
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/genre"
+	"github.com/mhvn092/movie-go/internal/domain/movie"
+	"github.com/mhvn092/movie-go/internal/domain/staff"
+)
+
+const (
+	TypeMovie = "movie"
+	TypeStaff = "staff"
+	TypeGenre = "genre"
+)
+
+// GlobalSearchQuery is the query string of the global search. Types is a comma separated
+// subset of movie, staff and genre, and every type gets Limit results unless it has its own limit.
+type GlobalSearchQuery struct {
+	Term       string `query:"term"        validate:"required"`
+	Mode       string `query:"mode"        validate:"omitempty, one_of=auto exact fuzzy"`
+	Types      string `query:"types"`
+	Limit      int    `query:"limit"       validate:"omitempty, is_int, min=1, max=20"`
+	MovieLimit int    `query:"movie_limit" validate:"omitempty, is_int, min=1, max=20"`
+	StaffLimit int    `query:"staff_limit" validate:"omitempty, is_int, min=1, max=20"`
+	GenreLimit int    `query:"genre_limit" validate:"omitempty, is_int, min=1, max=20"`
+}
+
+type AutocompleteQuery struct {
+	Term  string `query:"term"  validate:"required"`
+	Types string `query:"types"`
+	Limit int    `query:"limit" validate:"omitempty, is_int, min=1, max=20"`
+}
+
+type ResultGroup[T any] struct {
+	Items      []T    `json:"items"`
+	Mode       string `json:"mode,omitempty"`
+	Suggestion string `json:"did_you_mean,omitempty"`
+}
+
+// GlobalSearchResponse has a group for every searched type, each ranked on its own.
+type GlobalSearchResponse struct {
+	Term   string                                  `json:"term"`
+	Movies *ResultGroup[movie.MovieSearchResponse] `json:"movies,omitempty"`
+	Staff  *ResultGroup[staff.StaffSearchResponse] `json:"staff,omitempty"`
+	Genres *ResultGroup[genre.GenreSearchResponse] `json:"genres,omitempty"`
+}
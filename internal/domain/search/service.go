@@ -0,0 +1,196 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mhvn092/movie-go/internal/domain/genre"
+	"github.com/mhvn092/movie-go/internal/domain/movie"
+	"github.com/mhvn092/movie-go/internal/domain/staff"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+const (
+	DefaultGroupLimit        = 5
+	DefaultAutocompleteLimit = 8
+)
+
+var allTypes = []string{TypeMovie, TypeStaff, TypeGenre}
+
+type SearchService struct {
+	movieService *movie.MovieService
+	staffService *staff.StaffService
+	genreService *genre.GenreService
+}
+
+func NewSearchService(
+	movieService *movie.MovieService,
+	staffService *staff.StaffService,
+	genreService *genre.GenreService,
+) *SearchService {
+	return &SearchService{
+		movieService: movieService,
+		staffService: staffService,
+		genreService: genreService,
+	}
+}
+
+// Search queries every requested type in parallel. Movies and staff go through their own
+// search, so each group carries the mode it was found in and a "did you mean" suggestion.
+func (s *SearchService) Search(query GlobalSearchQuery) (res GlobalSearchResponse, err error) {
+	types, err := parseTypes(query.Types)
+	if err != nil {
+		return res, err
+	}
+
+	res.Term = strings.TrimSpace(query.Term)
+	search := web.SearchQuery{Term: res.Term, Mode: query.Mode}
+	limit := orDefault(query.Limit, DefaultGroupLimit)
+
+	var tasks []func() error
+	if types[TypeMovie] {
+		tasks = append(tasks, func() error {
+			p := web.FirstPage(movie.SearchPagination, uint64(orDefault(query.MovieLimit, limit)))
+			page, err := s.movieService.GetSearchResults(search, movie.MovieSearchFilter{}, p)
+			if err != nil {
+				return err
+			}
+			res.Movies = &ResultGroup[movie.MovieSearchResponse]{
+				Items:      page.Items,
+				Mode:       page.Mode,
+				Suggestion: page.Suggestion,
+			}
+			return nil
+		})
+	}
+	if types[TypeStaff] {
+		tasks = append(tasks, func() error {
+			p := web.FirstPage(staff.SearchPagination, uint64(orDefault(query.StaffLimit, limit)))
+			page, err := s.staffService.GetSearchResults(search, p)
+			if err != nil {
+				return err
+			}
+			res.Staff = &ResultGroup[staff.StaffSearchResponse]{
+				Items:      page.Items,
+				Mode:       page.Mode,
+				Suggestion: page.Suggestion,
+			}
+			return nil
+		})
+	}
+	if types[TypeGenre] {
+		tasks = append(tasks, func() error {
+			items, err := s.genreService.GetSearchResults(res.Term, orDefault(query.GenreLimit, limit))
+			if err != nil {
+				return err
+			}
+			res.Genres = &ResultGroup[genre.GenreSearchResponse]{Items: items}
+			return nil
+		})
+	}
+
+	err = runParallel(tasks)
+	return
+}
+
+// Autocomplete returns a single ranking of the best matching titles and names across the
+// requested types. Matches at the start of a label come first.
+func (s *SearchService) Autocomplete(query AutocompleteQuery) ([]web.Suggestion, error) {
+	types, err := parseTypes(query.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	term := strings.TrimSpace(query.Term)
+	limit := orDefault(query.Limit, DefaultAutocompleteLimit)
+	sources := map[string]func(string, int) ([]web.Suggestion, error){
+		TypeMovie: s.movieService.Autocomplete,
+		TypeStaff: s.staffService.Autocomplete,
+		TypeGenre: s.genreService.Autocomplete,
+	}
+
+	var tasks []func() error
+	results := make([][]web.Suggestion, len(allTypes))
+	for i, t := range allTypes {
+		if !types[t] {
+			continue
+		}
+		tasks = append(tasks, func() (err error) {
+			results[i], err = sources[t](term, limit)
+			return
+		})
+	}
+
+	if err := runParallel(tasks); err != nil {
+		return nil, err
+	}
+
+	merged := []web.Suggestion{}
+	for _, items := range results {
+		merged = append(merged, items...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// runParallel runs the tasks concurrently and returns the error of the first failing one.
+func runParallel(tasks []func() error) error {
+	errs := make([]error, len(tasks))
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = task()
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTypes reads the comma separated types filter, where nothing means every type.
+func parseTypes(typesStr string) (map[string]bool, error) {
+	types := make(map[string]bool, len(allTypes))
+	if strings.TrimSpace(typesStr) == "" {
+		for _, t := range allTypes {
+			types[t] = true
+		}
+		return types, nil
+	}
+
+	for _, t := range strings.Split(typesStr, ",") {
+		t = strings.TrimSpace(t)
+		if t != TypeMovie && t != TypeStaff && t != TypeGenre {
+			return nil, exception.InvalidFields(
+				"search",
+				"Invalid parameter",
+				[]exception.FieldError{
+					{Field: "types", Message: "can only contain " + strings.Join(allTypes, ", ")},
+				},
+			)
+		}
+		types[t] = true
+	}
+	return types, nil
+}
+
+func orDefault(value int, fallback int) int {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
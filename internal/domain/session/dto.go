@@ -0,0 +1,18 @@
+package session
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required, is_string"`
+}
+
+// ClientInfo describes where a session was started from.
+type ClientInfo struct {
+	UserAgent string
+	Ip        string
+}
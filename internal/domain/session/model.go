@@ -0,0 +1,24 @@
+package session
+
+import "time"
+
+// Session is one login of a user. Its refresh tokens form a family: each refresh uses up
+// the current token and issues the next, and revoking the session ends them all.
+type Session struct {
+	Id         int        `db:"id"`
+	UserId     int        `db:"user_id"`
+	UserAgent  string     `db:"user_agent"`
+	Ip         string     `db:"ip"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+type RefreshToken struct {
+	Id        int        `db:"id"`
+	SessionId int        `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
@@ -0,0 +1,197 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type SessionRepository struct {
+	*repository.BaseRepository
+}
+
+func NewSessionRepository(base *repository.BaseRepository) *SessionRepository {
+	return &SessionRepository{BaseRepository: base}
+}
+
+// invalidRefreshToken is returned for unknown, expired and revoked tokens alike.
+func invalidRefreshToken() error {
+	return exception.Unauthorized("session", "refresh token is invalid or expired")
+}
+
+// createSession starts a session with its first refresh token.
+func (r *SessionRepository) createSession(
+	userId int,
+	client ClientInfo,
+	tokenHash string,
+	expiresAt time.Time,
+) (sessionId int, err error) {
+	err = r.InTransaction(func(tx pgx.Tx) error {
+		err := tx.QueryRow(
+			context.Background(),
+			"insert into person.session (user_id, user_agent, ip) values ($1, left($2, 255), left($3, 64)) returning id",
+			userId,
+			client.UserAgent,
+			client.Ip,
+		).Scan(&sessionId)
+		if err != nil {
+			return err
+		}
+		return insertRefreshToken(tx, sessionId, tokenHash, expiresAt)
+	})
+	return
+}
+
+func insertRefreshToken(tx pgx.Tx, sessionId int, tokenHash string, expiresAt time.Time) error {
+	_, err := tx.Exec(
+		context.Background(),
+		"insert into person.refresh_token (session_id, token_hash, expires_at) values ($1, $2, $3)",
+		sessionId,
+		tokenHash,
+		expiresAt.UTC(),
+	)
+	return err
+}
+
+// rotateRefreshToken uses up the refresh token with tokenHash and stores newHash as its
+// successor. Presenting a token that was already used means it leaked, so the whole
+// session is revoked. It returns the current data of the session's user for the new access token.
+func (r *SessionRepository) rotateRefreshToken(
+	tokenHash string,
+	newHash string,
+	expiresAt time.Time,
+) (data security.UserTokenData, err error) {
+	reused := false
+
+	err = r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		var tokenId int
+		var tokenExpiresAt time.Time
+		var usedAt, revokedAt, disabledAt *time.Time
+		err := tx.QueryRow(
+			ctx,
+			`select rt.id, rt.expires_at, rt.used_at, s.id, s.revoked_at, u.disabled_at, u.id, u.email, u.role, `+
+				repository.RolePermissionsExpr("u.role")+`
+    from person.refresh_token rt
+    join person.session s on s.id = rt.session_id
+    join person.users u on u.id = s.user_id
+    where rt.token_hash = $1
+    for update of rt, s`,
+			tokenHash,
+		).Scan(
+			&tokenId,
+			&tokenExpiresAt,
+			&usedAt,
+			&data.SessionId,
+			&revokedAt,
+			&disabledAt,
+			&data.ID,
+			&data.Email,
+			&data.Role,
+			&data.Permissions,
+		)
+		if err == pgx.ErrNoRows || (err == nil && (revokedAt != nil || disabledAt != nil)) {
+			return invalidRefreshToken()
+		}
+		if err != nil {
+			return err
+		}
+
+		if usedAt != nil {
+			reused = true
+			_, err = tx.Exec(
+				ctx,
+				"update person.session set revoked_at = current_timestamp where id = $1",
+				data.SessionId,
+			)
+			return err
+		}
+
+		if time.Now().UTC().After(tokenExpiresAt) {
+			return invalidRefreshToken()
+		}
+
+		_, err = tx.Exec(ctx, "update person.refresh_token set used_at = current_timestamp where id = $1", tokenId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.session set last_used_at = current_timestamp where id = $1",
+			data.SessionId,
+		)
+		if err != nil {
+			return err
+		}
+
+		return insertRefreshToken(tx, data.SessionId, newHash, expiresAt)
+	})
+
+	if err == nil && reused {
+		err = exception.Unauthorized("session", "refresh token was already used, the session has been revoked")
+	}
+	return
+}
+
+func (r *SessionRepository) revokeSession(userId int, sessionId int) error {
+	_, err := r.DB.Exec(
+		context.Background(),
+		"update person.session set revoked_at = current_timestamp where id = $1 and user_id = $2 and revoked_at is null",
+		sessionId,
+		userId,
+	)
+	return err
+}
+
+func (r *SessionRepository) revokeAllSessions(userId int) error {
+	_, err := r.DB.Exec(
+		context.Background(),
+		"update person.session set revoked_at = current_timestamp where user_id = $1 and revoked_at is null",
+		userId,
+	)
+	return err
+}
+
+// revokeTokenId denies a single access token until it expires on its own. Entries of
+// tokens that expired by now are dropped on the way.
+func (r *SessionRepository) revokeTokenId(jti string, expiresAt time.Time) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+		_, err := tx.Exec(ctx, "delete from person.revoked_token where expires_at < $1", time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			ctx,
+			"insert into person.revoked_token (jti, expires_at) values ($1, $2) on conflict do nothing",
+			jti,
+			expiresAt.UTC(),
+		)
+		return err
+	})
+}
+
+// isRevoked reports whether the access token is denied, its session is gone or revoked, or
+// its user was disabled.
+func (r *SessionRepository) isRevoked(sessionId int, jti string) (bool, error) {
+	var revoked bool
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select
+    not exists (
+    select 1 from person.session s join person.users u on u.id = s.user_id
+    where s.id = $1 and s.revoked_at is null and u.disabled_at is null
+    )
+    or exists (select 1 from person.revoked_token where jti = $2)`,
+		sessionId,
+		jti,
+	).Scan(&revoked)
+	return revoked, err
+}
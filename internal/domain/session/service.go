@@ -0,0 +1,92 @@
+package session
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+)
+
+type SessionService struct {
+	repo *SessionRepository
+}
+
+func NewSessionService(repo *SessionRepository) *SessionService {
+	return &SessionService{repo: repo}
+}
+
+// Start opens a session for a user who just proved who they are.
+func (s *SessionService) Start(data security.UserTokenData, client ClientInfo) (TokenResponse, error) {
+	refreshToken, hash, err := security.NewOpaqueToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	data.SessionId, err = s.repo.createSession(data.ID, client, hash, time.Now().Add(security.RefreshTokenTTL()))
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return newTokenResponse(data, refreshToken)
+}
+
+// Refresh trades a refresh token for a new access token and the next refresh token.
+func (s *SessionService) Refresh(payload *RefreshPayload) (TokenResponse, error) {
+	refreshToken, hash, err := security.NewOpaqueToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	data, err := s.repo.rotateRefreshToken(
+		security.HashToken(strings.TrimSpace(payload.RefreshToken)),
+		hash,
+		time.Now().Add(security.RefreshTokenTTL()),
+	)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return newTokenResponse(data, refreshToken)
+}
+
+// Logout ends the session the access token belongs to, and the token itself right away.
+func (s *SessionService) Logout(claims *security.UserClaims) error {
+	if err := s.repo.revokeSession(claims.Id, claims.SessionId); err != nil {
+		return err
+	}
+	return s.revokeAccessToken(claims)
+}
+
+// LogoutAll ends every session of the user, on every device.
+func (s *SessionService) LogoutAll(claims *security.UserClaims) error {
+	if err := s.repo.revokeAllSessions(claims.Id); err != nil {
+		return err
+	}
+	return s.revokeAccessToken(claims)
+}
+
+// IsRevoked is the security.RevocationChecker backed by the sessions.
+func (s *SessionService) IsRevoked(claims *security.UserClaims) (bool, error) {
+	return s.repo.isRevoked(claims.SessionId, claims.ID)
+}
+
+func (s *SessionService) revokeAccessToken(claims *security.UserClaims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return s.repo.revokeTokenId(claims.ID, claims.ExpiresAt.Time)
+}
+
+func newTokenResponse(data security.UserTokenData, refreshToken string) (TokenResponse, error) {
+	accessToken, err := security.CreateToken(data)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken:  accessToken.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(accessToken.ExpiresAt).Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
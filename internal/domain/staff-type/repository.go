@@ -4,57 +4,80 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/mhvn092/movie-go/internal/platform/repository"
 	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type StaffTypeRepository struct {
 	*repository.BaseRepository
 }
 
+// ListPagination lists the fields staff types can be sorted by.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":         {Expr: "id", Type: "integer"},
+		"title":      {Expr: "title", Type: "text"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	DefaultSort: "id",
+}
+
 func NewStaffTypeRepository(base *repository.BaseRepository) *StaffTypeRepository {
 	return &StaffTypeRepository{BaseRepository: base}
 }
 
 func (r *StaffTypeRepository) getAllStaffTypesPaginated(
 	params web.PaginationParam,
-) (res []StaffType, nextCursor int, err error) {
+) (page web.Page[StaffType], err error) {
+	keyset, err := web.NewKeyset(params, ListPagination, 1)
+	if err != nil {
+		return page, err
+	}
+
 	rows, err := r.DB.Query(
 		context.Background(),
-		"select id, title from staff.staff_type where id >= $1 limit $2",
-		params.CursorID,
-		params.Limit,
+		fmt.Sprintf(
+			"select id, title, %s from staff.staff_type %s order by %s limit %d",
+			keyset.SelectColumns(),
+			web.WhereClause(keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		keyset.Args()...,
 	)
-
-	defer rows.Close()
-
 	if err != nil {
-		return nil, 0, err
+		return page, err
 	}
 
-	res = []StaffType{}
+	defer rows.Close()
+
+	res := []StaffType{}
+	rowKeys := [][]string{}
 
 	for rows.Next() {
 		var item StaffType
-		err = rows.Scan(
-			&item.Id,
-			&item.Title,
-		)
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(append([]interface{}{&item.Id, &item.Title}, keyDest...)...)
 		if err != nil {
 			return
 		}
 		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
 	}
 
-	if len(res) > 0 {
-		nextCursor = res[len(res)-1].Id + 1
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from staff.staff_type")
 	}
 
 	return
@@ -76,39 +99,8 @@ func (r *StaffTypeRepository) checkIfExists(query string, args ...interface{}) (
 	return true, nil
 }
 
-func (r *StaffTypeRepository) checkCountOfExistingIds(ids []int) (bool, error) {
-	currentCount := len(ids)
-	if currentCount == 0 {
-		return false, nil
-	}
-
-	placeholders := make([]string, currentCount)
-	args := make([]interface{}, currentCount)
-
-	for i, id := range ids {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = id
-	}
-
-	query := fmt.Sprintf(
-		"select count(id) from staff.staff_type where id in (%s)",
-		strings.Join(placeholders, ","),
-	)
-
-	var existingCount int
-	err := r.DB.QueryRow(
-		context.Background(),
-		query,
-		args...,
-	).Scan(&existingCount)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return false, nil
-		}
-		return false, err
-	}
-
-	return existingCount == currentCount, nil
+func (r *StaffTypeRepository) findMissingIds(ids []int) ([]int, error) {
+	return r.FindMissingIds("staff.staff_type", ids)
 }
 
 func (r *StaffTypeRepository) checkIfExistsByTitle(title string) (bool, error) {
@@ -134,7 +126,7 @@ func (r *StaffTypeRepository) insert(staffType *StaffType) (int, error) {
 	}
 
 	if exists {
-		return 0, errors.New(strconv.Itoa(http.StatusConflict))
+		return 0, exception.Conflict("staff type")
 	}
 
 	var staffTypeId int
@@ -168,7 +160,7 @@ func (r *StaffTypeRepository) edit(id int, staffType *StaffType) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("staff type", id)
 	}
 
 	exists, err = r.checkIfExistsByNameExcludingId(id, staffType.Title)
@@ -177,7 +169,7 @@ func (r *StaffTypeRepository) edit(id int, staffType *StaffType) error {
 	}
 
 	if exists {
-		return errors.New(strconv.Itoa(http.StatusConflict))
+		return exception.Conflict("staff type")
 	}
 
 	cmdTag, err := r.DB.Exec(
@@ -206,7 +198,7 @@ func (r *StaffTypeRepository) delete(id int) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("staff type", id)
 	}
 	cmdTag, err := r.DB.Exec(
 		context.Background(),
@@ -10,7 +10,7 @@ func NewStaffTypeService(repo *StaffTypeRepository) *StaffTypeService {
 	return &StaffTypeService{repo: repo}
 }
 
-func (s *StaffTypeService) GetAllPaginated(p web.PaginationParam) ([]StaffType, int, error) {
+func (s *StaffTypeService) GetAllPaginated(p web.PaginationParam) (web.Page[StaffType], error) {
 	return s.repo.getAllStaffTypesPaginated(p)
 }
 
@@ -30,6 +30,6 @@ func (s *StaffTypeService) CheckIfExists(id int) (bool, error) {
 	return s.repo.checkIfExistsById(id)
 }
 
-func (s *StaffTypeService) CheckCountOfExistingIds(ids []int) (bool, error) {
-	return s.repo.checkCountOfExistingIds(ids)
+func (s *StaffTypeService) FindMissingIds(ids []int) ([]int, error) {
+	return s.repo.findMissingIds(ids)
 }
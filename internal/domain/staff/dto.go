@@ -1,6 +1,10 @@
 package staff
 
-import "time"
+import (
+	"time"
+
+	"github.com/mhvn092/movie-go/internal/platform/web"
+)
 
 type StaffGetAllResponse struct {
 	Id        int    `json:"id"         db:"id"`
@@ -8,6 +12,22 @@ type StaffGetAllResponse struct {
 	LastName  string `json:"last_name"  db:"last_name"`
 }
 
+type StaffSearchResponse struct {
+	Id        int     `json:"id"         db:"id"`
+	FirstName string  `json:"first_name" db:"first_name"`
+	LastName  string  `json:"last_name"  db:"last_name"`
+	Rank      float32 `json:"rank"       db:"rank"`
+	Highlight string  `json:"highlight,omitempty" db:"highlight"`
+}
+
+// StaffSearchPage tells which mode produced the results and, when nothing matched exactly,
+// the closest name.
+type StaffSearchPage struct {
+	web.Page[StaffSearchResponse]
+	Mode       string `json:"mode"`
+	Suggestion string `json:"did_you_mean,omitempty"`
+}
+
 type StaffGetDetailResponse struct {
 	Id             int       `json:"id"`
 	FirstName      string    `json:"first_name"`
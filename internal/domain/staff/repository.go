@@ -4,58 +4,112 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/mhvn092/movie-go/internal/platform/repository"
 	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type StaffRepository struct {
 	*repository.BaseRepository
 }
 
+// ListPagination lists the fields staff can be sorted by.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":         {Expr: "id", Type: "integer"},
+		"first_name": {Expr: "first_name", Type: "text"},
+		"last_name":  {Expr: "last_name", Type: "text"},
+		"birth_date": {Expr: "birth_date", Type: "timestamp"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	DefaultSort: "id",
+}
+
+// SearchPagination lists the fields staff search results can be sorted by, best match first by default.
+// The rank uses the A (name) and B (bio) weights set by staff.staff_search_trigger.
+var SearchPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"rank": {
+			Expr: "ts_rank_cd('{0.1, 0.2, 0.4, 1.0}', s.search_vector, query)",
+			Type: "real",
+		},
+		"id":         {Expr: "s.id", Type: "integer"},
+		"first_name": {Expr: "s.first_name", Type: "text"},
+		"last_name":  {Expr: "s.last_name", Type: "text"},
+	},
+	DefaultSort: "-rank",
+}
+
+// FuzzySearchPagination is SearchPagination for typo tolerant search, ranked by the trigram
+// word similarity of the full name.
+var FuzzySearchPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"rank": {
+			Expr: "word_similarity($1, s.first_name || ' ' || s.last_name)",
+			Type: "real",
+		},
+		"id":         {Expr: "s.id", Type: "integer"},
+		"first_name": {Expr: "s.first_name", Type: "text"},
+		"last_name":  {Expr: "s.last_name", Type: "text"},
+	},
+	DefaultSort: "-rank",
+}
+
 func NewStaffRepository(base *repository.BaseRepository) *StaffRepository {
 	return &StaffRepository{BaseRepository: base}
 }
 
 func (r *StaffRepository) getAllStaffPaginated(
 	params web.PaginationParam,
-) (res []StaffGetAllResponse, nextCursor int, err error) {
+) (page web.Page[StaffGetAllResponse], err error) {
+	keyset, err := web.NewKeyset(params, ListPagination, 1)
+	if err != nil {
+		return page, err
+	}
+
 	rows, err := r.DB.Query(
 		context.Background(),
-		"select id, first_name, last_name from staff.staff where id >= $1 limit $2",
-		params.CursorID,
-		params.Limit,
+		fmt.Sprintf(
+			"select id, first_name, last_name, %s from staff.staff %s order by %s limit %d",
+			keyset.SelectColumns(),
+			web.WhereClause(keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		keyset.Args()...,
 	)
-
-	defer rows.Close()
-
 	if err != nil {
-		return nil, 0, err
+		return page, err
 	}
 
-	res = []StaffGetAllResponse{}
+	defer rows.Close()
+
+	res := []StaffGetAllResponse{}
+	rowKeys := [][]string{}
 
 	for rows.Next() {
 		var item StaffGetAllResponse
-		err = rows.Scan(
-			&item.Id,
-			&item.FirstName,
-			&item.LastName,
-		)
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(append([]interface{}{&item.Id, &item.FirstName, &item.LastName}, keyDest...)...)
 		if err != nil {
 			return
 		}
 		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
 	}
 
-	if len(res) > 0 {
-		nextCursor = res[len(res)-1].Id + 1
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from staff.staff")
 	}
 
 	return
@@ -63,91 +117,188 @@ func (r *StaffRepository) getAllStaffPaginated(
 
 func (r *StaffRepository) getSearchResults(
 	searchTerm string,
-) (res []StaffGetAllResponse, err error) {
-	terms := strings.Fields(searchTerm)
-	queryTerm := strings.Join(terms, " <-> ") + ":*"
+	params web.PaginationParam,
+) (page web.Page[StaffSearchResponse], err error) {
+	keyset, err := web.NewKeyset(params, SearchPagination, 2)
+	if err != nil {
+		return page, err
+	}
+
+	from := "from staff.staff s, to_tsquery('simple', $1) query"
+	where := web.WhereClause("s.search_vector @@ query", keyset.Condition())
+
 	rows, err := r.DB.Query(
 		context.Background(),
-		`SELECT id, first_name, last_name
-    FROM staff.staff
-    WHERE search_vector @@ to_tsquery('simple', $1)
-    LIMIT 10;`,
-		queryTerm,
+		fmt.Sprintf(
+			`select s.id, s.first_name, s.last_name, %s, ts_headline('simple', s.bio, query, '%s'), %s
+    %s
+    %s
+    order by %s
+    limit %d`,
+			SearchPagination.Columns["rank"].Expr,
+			repository.HeadlineOptions,
+			keyset.SelectColumns(),
+			from,
+			where,
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append([]interface{}{searchTerm}, keyset.Args()...)...,
 	)
-
-	defer rows.Close()
-
 	if err != nil {
-		fmt.Println("the error is the in the query itself")
-		return nil, err
+		return page, err
 	}
 
-	res = []StaffGetAllResponse{}
+	defer rows.Close()
+
+	res := []StaffSearchResponse{}
+	rowKeys := [][]string{}
 
 	for rows.Next() {
-		var item StaffGetAllResponse
+		var item StaffSearchResponse
+		keys, keyDest := keyset.RowKeys()
 		err = rows.Scan(
-			&item.Id,
-			&item.FirstName,
-			&item.LastName,
+			append([]interface{}{&item.Id, &item.FirstName, &item.LastName, &item.Rank, &item.Highlight}, keyDest...)...,
 		)
 		if err != nil {
 			return
 		}
+		item.Highlight = repository.Headline(item.Highlight)
 		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows(
+			"select count(*) "+from+" where s.search_vector @@ query",
+			searchTerm,
+		)
 	}
 
 	return
 }
 
-func (r *StaffRepository) checkIfExists(id int) (bool, error) {
-	var staffId int
-	err := r.DB.QueryRow(
+func (r *StaffRepository) getFuzzySearchResults(
+	searchTerm string,
+	params web.PaginationParam,
+) (page web.Page[StaffSearchResponse], err error) {
+	keyset, err := web.NewKeyset(params, FuzzySearchPagination, 2)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
 		context.Background(),
-		"select id from staff.staff where id = $1",
-		id,
-	).Scan(&staffId)
+		fmt.Sprintf(
+			`select s.id, s.first_name, s.last_name, %s, %s
+    from staff.staff s
+    %s
+    order by %s
+    limit %d`,
+			FuzzySearchPagination.Columns["rank"].Expr,
+			keyset.SelectColumns(),
+			web.WhereClause("$1 <% (s.first_name || ' ' || s.last_name)", keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append([]interface{}{searchTerm}, keyset.Args()...)...,
+	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return false, nil
+		return page, err
+	}
+
+	defer rows.Close()
+
+	res := []StaffSearchResponse{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item StaffSearchResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(append([]interface{}{&item.Id, &item.FirstName, &item.LastName, &item.Rank}, keyDest...)...)
+		if err != nil {
+			return
 		}
-		return false, err
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
 	}
-	return true, nil
-}
 
-func (r *StaffRepository) checkCountOfExistingIds(ids []int) (bool, error) {
-	currentCount := len(ids)
-	if currentCount == 0 {
-		return false, nil
+	if err = rows.Err(); err != nil {
+		return
 	}
 
-	placeholders := make([]string, currentCount)
-	args := make([]interface{}, currentCount)
+	page = web.NewPage(res, rowKeys, params)
 
-	for i, id := range ids {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = id
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from staff.staff s where $1 <% (s.first_name || ' ' || s.last_name)", searchTerm)
 	}
 
-	query := fmt.Sprintf(
-		"select count(id) from staff.staff where id in (%s)",
-		strings.Join(placeholders, ","),
+	return
+}
+
+// getSearchSuggestion returns the full name closest to the term, for "did you mean".
+func (r *StaffRepository) getSearchSuggestion(searchTerm string) (string, error) {
+	var name string
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select first_name || ' ' || last_name from staff.staff
+    where $1 <% (first_name || ' ' || last_name)
+    order by word_similarity($1, first_name || ' ' || last_name) desc, id
+    limit 1`,
+		searchTerm,
+	).Scan(&name)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return name, nil
+}
+
+// getAutocomplete returns the full names matching a partially typed term, best match first.
+func (r *StaffRepository) getAutocomplete(searchTerm string, limit int) ([]web.Suggestion, error) {
+	return r.QuerySuggestions(
+		"staff",
+		fmt.Sprintf(
+			`select id, first_name || ' ' || last_name, %s as score
+    from staff.staff
+    where %s
+    order by score desc, id
+    limit $3`,
+			repository.AutocompleteScore("(first_name || ' ' || last_name)"),
+			repository.AutocompleteCondition("(first_name || ' ' || last_name)"),
+		),
+		repository.EscapeLike(searchTerm),
+		searchTerm,
+		limit,
 	)
+}
 
-	var existingCount int
+func (r *StaffRepository) checkIfExists(id int) (bool, error) {
+	var staffId int
 	err := r.DB.QueryRow(
 		context.Background(),
-		query,
-		args...,
-	).Scan(&existingCount)
+		"select id from staff.staff where id = $1",
+		id,
+	).Scan(&staffId)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return false, nil
 		}
 		return false, err
 	}
-	return existingCount == currentCount, nil
+	return true, nil
+}
+
+func (r *StaffRepository) findMissingIds(ids []int) ([]int, error) {
+	return r.FindMissingIds("staff.staff", ids)
 }
 
 func (r *StaffRepository) getDetail(id int) (StaffGetDetailResponse, error) {
@@ -159,7 +310,7 @@ func (r *StaffRepository) getDetail(id int) (StaffGetDetailResponse, error) {
 	).Scan(&staff.Id, &staff.FirstName, &staff.LastName, &staff.Bio, &staff.BirthDate, &staff.StaffTypeId, &staff.StaffTypeTitle)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return staff, errors.New(strconv.Itoa(http.StatusNotFound))
+			return staff, exception.NotFound("staff", id)
 		}
 		return staff, err
 	}
@@ -202,7 +353,7 @@ func (r *StaffRepository) edit(id int, staff *Staff) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("staff", id)
 	}
 
 	cmdTag, err := r.DB.Exec(
@@ -234,7 +385,7 @@ func (r *StaffRepository) delete(id int) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("staff", id)
 	}
 
 	cmdTag, err := r.DB.Exec(
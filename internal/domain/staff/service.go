@@ -1,12 +1,12 @@
 package staff
 
 import (
-	"errors"
-	"net/http"
-	"strconv"
+	"strings"
 
 	stafftype "github.com/mhvn092/movie-go/internal/domain/staff-type"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
 	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type StaffService struct {
@@ -21,20 +21,68 @@ func NewStaffService(
 	return &StaffService{repo: repo, staffTypeService: staffTypeService}
 }
 
-func (s *StaffService) GetAllPaginated(p web.PaginationParam) ([]StaffGetAllResponse, int, error) {
+func (s *StaffService) GetAllPaginated(p web.PaginationParam) (web.Page[StaffGetAllResponse], error) {
 	return s.repo.getAllStaffPaginated(p)
 }
 
-func (s *StaffService) GetSearchResults(searchTerm string) ([]StaffGetAllResponse, error) {
-	return s.repo.getSearchResults(searchTerm)
+// GetSearchResults runs a full text search, or a trigram search in fuzzy mode. When the first
+// page of a full text search is empty it suggests the closest match, and in auto mode
+// returns the fuzzy results instead.
+func (s *StaffService) GetSearchResults(
+	search web.SearchQuery,
+	p web.PaginationParam,
+) (result StaffSearchPage, err error) {
+	searchTerm := strings.TrimSpace(search.Term)
+
+	mode, err := search.PageMode("staff", p)
+	if err != nil {
+		return result, err
+	}
+
+	if mode == web.SearchModeFuzzy {
+		result.Mode = web.SearchModeFuzzy
+		p.Mode = web.SearchModeFuzzy
+		result.Page, err = s.repo.getFuzzySearchResults(searchTerm, p)
+		return
+	}
+
+	query := repository.ToPrefixTsQuery(searchTerm)
+	if query == "" {
+		return result, exception.InvalidFields(
+			"staff",
+			"Invalid parameter",
+			[]exception.FieldError{{Field: "term", Message: "must contain a letter or a digit"}},
+		)
+	}
+
+	result.Mode = web.SearchModeExact
+	p.Mode = web.SearchModeExact
+	result.Page, err = s.repo.getSearchResults(query, p)
+	if err != nil || p.Cursor != nil || len(result.Items) > 0 {
+		return
+	}
+
+	result.Suggestion, err = s.repo.getSearchSuggestion(searchTerm)
+	if err != nil || mode == web.SearchModeExact {
+		return
+	}
+
+	result.Mode = web.SearchModeFuzzy
+	p.Mode = web.SearchModeFuzzy
+	result.Page, err = s.repo.getFuzzySearchResults(searchTerm, p)
+	return
+}
+
+func (s *StaffService) Autocomplete(term string, limit int) ([]web.Suggestion, error) {
+	return s.repo.getAutocomplete(strings.TrimSpace(term), limit)
 }
 
 func (s *StaffService) CheckIfExists(id int) (bool, error) {
 	return s.repo.checkIfExists(id)
 }
 
-func (s *StaffService) CheckCountOfExistingIds(ids []int) (bool, error) {
-	return s.repo.checkCountOfExistingIds(ids)
+func (s *StaffService) FindMissingIds(ids []int) ([]int, error) {
+	return s.repo.findMissingIds(ids)
 }
 
 func (s *StaffService) Insert(staff *Staff) (int, error) {
@@ -44,7 +92,7 @@ func (s *StaffService) Insert(staff *Staff) (int, error) {
 	}
 
 	if !exists {
-		return 0, errors.New(strconv.Itoa(http.StatusNotFound))
+		return 0, exception.NotFound("staff type", staff.StaffTypeId)
 	}
 
 	return s.repo.insert(staff)
@@ -61,7 +109,7 @@ func (s *StaffService) Edit(id int, staff *Staff) error {
 	}
 
 	if !exists {
-		return errors.New(strconv.Itoa(http.StatusNotFound))
+		return exception.NotFound("staff type", staff.StaffTypeId)
 	}
 
 	return s.repo.edit(id, staff)
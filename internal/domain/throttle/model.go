@@ -0,0 +1,39 @@
+package throttle
+
+import "time"
+
+// Key names what failed attempts are counted against, like the account an email belongs
+// to or the address requests come from.
+type Key struct {
+	Kind    string
+	Subject string
+}
+
+func AccountKey(email string) Key {
+	return Key{Kind: "account", Subject: email}
+}
+
+func IpKey(ip string) Key {
+	return Key{Kind: "ip", Subject: ip}
+}
+
+// Policy lets FreeAttempts failures through, then locks the key for BaseLockout, doubling
+// with every further failure up to MaxLockout. Failures older than Window are forgotten.
+type Policy struct {
+	FreeAttempts int
+	BaseLockout  time.Duration
+	MaxLockout   time.Duration
+	Window       time.Duration
+}
+
+func (p Policy) lockoutAfter(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := p.FreeAttempts + 1; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, p.MaxLockout)
+}
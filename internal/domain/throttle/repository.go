@@ -0,0 +1,89 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+)
+
+type ThrottleRepository struct {
+	*repository.BaseRepository
+}
+
+func NewThrottleRepository(base *repository.BaseRepository) *ThrottleRepository {
+	return &ThrottleRepository{BaseRepository: base}
+}
+
+// getLockedUntil returns the end of the longest running lockout of the keys, or nil.
+func (r *ThrottleRepository) getLockedUntil(keys []Key, now time.Time) (*time.Time, error) {
+	kinds := make([]string, len(keys))
+	subjects := make([]string, len(keys))
+	for i, key := range keys {
+		kinds[i] = key.Kind
+		subjects[i] = key.Subject
+	}
+
+	var lockedUntil *time.Time
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select max(locked_until) from person.login_throttle
+    where (kind, subject) in (select kind, left(subject, 255) from unnest($1::varchar[], $2::varchar[]) as k (kind, subject)) and locked_until > $3`,
+		kinds,
+		subjects,
+		now.UTC(),
+	).Scan(&lockedUntil)
+	return lockedUntil, err
+}
+
+// recordFailure counts a failed attempt against the key and locks it as the policy says.
+func (r *ThrottleRepository) recordFailure(key Key, policy Policy, now time.Time) error {
+	now = now.UTC()
+
+	return r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		var failures int
+		err := tx.QueryRow(
+			ctx,
+			`insert into person.login_throttle as t (kind, subject, failures, last_failed_at) values ($1, left($2, 255), 1, $3)
+    on conflict (kind, subject) do update set
+    failures = case when t.last_failed_at < $4 then 1 else t.failures + 1 end,
+    last_failed_at = $3
+    returning failures`,
+			key.Kind,
+			key.Subject,
+			now,
+			now.Add(-policy.Window),
+		).Scan(&failures)
+		if err != nil {
+			return err
+		}
+
+		lockout := policy.lockoutAfter(failures)
+		if lockout == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.login_throttle set locked_until = $3 where kind = $1 and subject = left($2, 255)",
+			key.Kind,
+			key.Subject,
+			now.Add(lockout),
+		)
+		return err
+	})
+}
+
+func (r *ThrottleRepository) reset(key Key) error {
+	_, err := r.DB.Exec(
+		context.Background(),
+		"delete from person.login_throttle where kind = $1 and subject = left($2, 255)",
+		key.Kind,
+		key.Subject,
+	)
+	return err
+}
@@ -0,0 +1,83 @@
+package throttle
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// policies are applied by key kind. An address is shared by many users behind the same
+// network, so it gets more attempts than a single account.
+var policies = map[string]Policy{
+	"account": {FreeAttempts: 5, BaseLockout: 30 * time.Second, MaxLockout: time.Hour, Window: 24 * time.Hour},
+	"ip":      {FreeAttempts: 20, BaseLockout: 30 * time.Second, MaxLockout: time.Hour, Window: 24 * time.Hour},
+}
+
+// policy returns the policy of the key kind, and false when keys of that kind aren't
+// throttled. LOGIN_IP_ATTEMPTS sets the attempts an address gets, 0 turns the address
+// lockout off for deployments where many users share one address.
+func policy(kind string) (Policy, bool) {
+	p := policies[kind]
+	if kind != "ip" {
+		return p, true
+	}
+
+	if value := env.GetEnv(env.LOGIN_IP_ATTEMPTS); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err == nil && attempts >= 0 {
+			p.FreeAttempts = attempts
+		}
+	}
+	return p, p.FreeAttempts > 0
+}
+
+// throttled drops the keys whose kind isn't throttled.
+func throttled(keys []Key) []Key {
+	var res []Key
+	for _, key := range keys {
+		if _, ok := policy(key.Kind); ok {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+type ThrottleService struct {
+	repo *ThrottleRepository
+}
+
+func NewThrottleService(repo *ThrottleRepository) *ThrottleService {
+	return &ThrottleService{repo: repo}
+}
+
+// Check refuses the attempt while any of the keys is locked out.
+func (s *ThrottleService) Check(keys ...Key) error {
+	now := time.Now()
+	lockedUntil, err := s.repo.getLockedUntil(throttled(keys), now)
+	if err != nil {
+		return err
+	}
+	if lockedUntil == nil {
+		return nil
+	}
+	return exception.TooManyRequests("login", "too many failed attempts, try again later", lockedUntil.Sub(now.UTC()))
+}
+
+// RecordFailure counts a failed attempt against every key.
+func (s *ThrottleService) RecordFailure(keys ...Key) error {
+	now := time.Now()
+	for _, key := range throttled(keys) {
+		p, _ := policy(key.Kind)
+		if err := s.repo.recordFailure(key, p, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset forgets the failed attempts of the key and lifts its lockout.
+func (s *ThrottleService) Reset(key Key) error {
+	return s.repo.reset(key)
+}
@@ -1,6 +1,117 @@
 package user
 
+import "time"
+
 type LoginDto struct {
 	Email    string `json:"email"    validate:"required, is_string, is_email"`
 	Password string `json:"password" validate:"required, is_string"`
 }
+
+type EmailDto struct {
+	Email string `json:"email" validate:"required, is_string, is_email"`
+}
+
+type ResetPasswordDto struct {
+	Token    string `json:"token"    validate:"required, is_string"`
+	Password string `json:"password" validate:"required, is_string, is_strong_password,min_len=10"`
+}
+
+type VerifyEmailDto struct {
+	Token string `json:"token" validate:"required, is_string"`
+}
+
+type ProfileResponse struct {
+	Id              int        `json:"id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Email           string     `json:"email"`
+	PhoneNumber     string     `json:"phone_number"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	// TwoFactorEnabled tells whether logging in asks for a code after the password
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+type ProfileUpdateDto struct {
+	FirstName   string `json:"first_name"   validate:"required, is_string, max_len=255"`
+	LastName    string `json:"last_name"    validate:"required, is_string, max_len=255"`
+	PhoneNumber string `json:"phone_number" validate:"required, is_string, is_phone_number"`
+}
+
+type ChangePasswordDto struct {
+	CurrentPassword string `json:"current_password" validate:"required, is_string"`
+	NewPassword     string `json:"new_password"     validate:"required, is_string, is_strong_password,min_len=10"`
+}
+
+type ChangeEmailDto struct {
+	Email    string `json:"email"    validate:"required, is_string, is_email"`
+	Password string `json:"password" validate:"required, is_string"`
+}
+
+type DeleteAccountDto struct {
+	Password string `json:"password" validate:"required, is_string"`
+}
+
+type UserListFilter struct {
+	Search string `query:"search" validate:"omitempty, is_string"`
+	Role   string `query:"role"   validate:"omitempty, is_string"`
+	Status string `query:"status" validate:"omitempty, one_of=active disabled"`
+}
+
+type UserListItemResponse struct {
+	Id         int        `json:"id"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+type UserDetailResponse struct {
+	ProfileResponse
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+type ChangeRoleDto struct {
+	Role string `json:"role" validate:"required, is_string"`
+}
+
+// TwoFactorChallengeResponse answers a correct password when a code is needed to finish
+// logging in. Setup is only set for users who have to enroll first.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool               `json:"two_factor_required"`
+	MfaToken          string             `json:"mfa_token"`
+	ExpiresIn         int                `json:"expires_in"`
+	Setup             *TotpSetupResponse `json:"setup,omitempty"`
+}
+
+type TotpSetupResponse struct {
+	Secret string `json:"secret"`
+	// OtpauthUri is the provisioning URI to show as a QR code
+	OtpauthUri string `json:"otpauth_uri"`
+}
+
+// TwoFactorLoginDto finishes a login with either a code of the authenticator app or a recovery code.
+type TwoFactorLoginDto struct {
+	MfaToken string `json:"mfa_token" validate:"required, is_string"`
+	Code     string `json:"code"      validate:"required, is_string, max_len=32"`
+}
+
+type TwoFactorSetupDto struct {
+	Password string `json:"password" validate:"required, is_string"`
+}
+
+type TwoFactorCodeDto struct {
+	Code string `json:"code" validate:"required, is_string, max_len=32"`
+}
+
+type PasswordAndCodeDto struct {
+	Password string `json:"password" validate:"required, is_string"`
+	Code     string `json:"code"     validate:"required, is_string, max_len=32"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,42 @@
+package user
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+	"github.com/mhvn092/movie-go/pkg/mailer"
+)
+
+func passwordResetMessage(u *User, token string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset the password of your account. If it was you, follow the link below within %s:\n\n%s\n\nIf it wasn't, you can ignore this email and your password stays the same.\n",
+			u.FirstName,
+			ttl,
+			tokenLink("/reset-password", token),
+		),
+	}
+}
+
+func emailVerificationMessage(u *User, token string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      u.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm this is your email address by following the link below within %s:\n\n%s\n",
+			u.FirstName,
+			ttl,
+			tokenLink("/verify-email", token),
+		),
+	}
+}
+
+// tokenLink points to the page of the client at APP_URL that posts the token back to the api.
+func tokenLink(path string, token string) string {
+	return strings.TrimSuffix(env.GetEnv(env.APP_URL), "/") + path + "?token=" + url.QueryEscape(token)
+}
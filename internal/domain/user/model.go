@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/env"
 )
 
 type UserRoleType string
@@ -24,15 +25,39 @@ type User struct {
 	LastName    string       `db:"last_name"    json:"last_name"    validate:"is_string"`
 	Email       string       `db:"email"        json:"email"        validate:"required, is_string, is_email"`
 	Password    string       `db:"password"     json:"password"     validate:"required, is_string, is_strong_password,min_len=10"`
-	Role        UserRoleType `db:"role"`
+	Role        UserRoleType `db:"role"         json:"-"`
 	PhoneNumber string       `db:"phone_number" json:"phone_number" validate:"required, is_string, is_phone_number"`
 	CreatedAt   time.Time    `db:"created_at"`
 	UpdatedAt   time.Time    `db:"updated_at"`
+	// EmailVerifiedAt is never taken from a payload, only set by following the mailed link
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"-"`
+	DisabledAt      *time.Time `db:"disabled_at"       json:"-"`
+	// TotpSecret is set once enrollment starts and only counts once TotpEnabledAt is set too
+	TotpSecret    *string    `db:"totp_secret"     json:"-"`
+	TotpEnabledAt *time.Time `db:"totp_enabled_at" json:"-"`
+	// Permissions are granted by the role and only loaded to issue tokens
+	Permissions []string `db:"-" json:"-"`
 }
 
+// TokenPurpose tells apart the single use tokens mailed to a user.
+type TokenPurpose string
+
+const (
+	PasswordResetToken     TokenPurpose = "password_reset"
+	EmailVerificationToken TokenPurpose = "email_verification"
+	TwoFactorLoginToken    TokenPurpose = "two_factor_login"
+)
+
+// recoveryCodeCount is how many recovery codes a user gets with two-factor authentication.
+const recoveryCodeCount = 10
+
+// twoFactorLoginAttempts is how many wrong codes the token of a second login step takes
+// before it stops working and the user has to enter their password again.
+const twoFactorLoginAttempts = 5
+
 // PrepareCreate Prepare user for register
 func (u *User) prepareToCreate() error {
-	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	u.Email = normalizeEmail(u.Email)
 	u.Password = strings.TrimSpace(u.Password)
 
 	hashedPassword, err := security.HashPassword(u.Password)
@@ -50,3 +75,11 @@ func (u *User) prepareToCreate() error {
 	u.UpdatedAt = time.Now()
 	return nil
 }
+
+// needsTwoFactorSetup reports whether the user has to enroll in two-factor authentication
+// before logging in, which REQUIRE_ADMIN_2FA asks of every admin.
+func (u *User) needsTwoFactorSetup() bool {
+	return u.TotpEnabledAt == nil &&
+		u.Role == UserRole.ADMIN &&
+		env.GetEnv(env.REQUIRE_ADMIN_2FA) == "true"
+}
@@ -3,18 +3,32 @@ package user
 import (
 	"context"
 	"errors"
-	"net/http"
-	"strconv"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 type UserRepository struct {
 	*repository.BaseRepository
 }
 
+// ListPagination lists the fields users can be sorted by, newest first by default.
+var ListPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"id":         {Expr: "u.id", Type: "integer"},
+		"email":      {Expr: "u.email", Type: "text"},
+		"last_name":  {Expr: "u.last_name", Type: "text"},
+		"created_at": {Expr: "u.created_at", Type: "timestamp"},
+	},
+	DefaultSort: "-id",
+}
+
 func NewUserRepository(base *repository.BaseRepository) *UserRepository {
 	return &UserRepository{BaseRepository: base}
 }
@@ -33,7 +47,7 @@ func (r *UserRepository) isUserAlreadyRegisted(email string) error {
 		return err
 	}
 
-	return errors.New(strconv.Itoa(http.StatusConflict))
+	return exception.Conflict("user")
 }
 
 func (r *UserRepository) registerUser(u *User) error {
@@ -46,9 +60,9 @@ func (r *UserRepository) registerUser(u *User) error {
 		return err
 	}
 
-	_, err = r.DB.Exec(
+	err = r.DB.QueryRow(
 		context.Background(),
-		"Insert into person.users (first_name, last_name, email, password, role, phone_number, created_at, updated_at) values($1, $2, $3,$4, $5,$6,$7,$8)",
+		"Insert into person.users (first_name, last_name, email, password, role, phone_number, created_at, updated_at, email_verified_at) values($1, $2, $3,$4, $5,$6,$7,$8,$9) returning id",
 		u.FirstName,
 		u.LastName,
 		u.Email,
@@ -57,7 +71,8 @@ func (r *UserRepository) registerUser(u *User) error {
 		u.PhoneNumber,
 		u.CreatedAt,
 		u.UpdatedAt,
-	)
+		u.EmailVerifiedAt,
+	).Scan(&u.Id)
 	if err != nil {
 		return err
 	}
@@ -66,19 +81,684 @@ func (r *UserRepository) registerUser(u *User) error {
 }
 
 func (r *UserRepository) checkUser(login *LoginDto) (*User, error) {
+	return r.findLoginUser("u.email = $1", login.Email)
+}
+
+// getLoginUser loads the user finishing a login, for the second step of two-factor authentication.
+func (r *UserRepository) getLoginUser(id int) (*User, error) {
+	return r.findLoginUser("u.id = $1", id)
+}
+
+// findLoginUser loads what logging in checks and the access tokens carry for the user matching where.
+func (r *UserRepository) findLoginUser(where string, arg interface{}) (*User, error) {
+	var user User
+
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select u.id, coalesce(u.password, ''), u.email, u.role, u.email_verified_at, u.disabled_at,
+    u.totp_secret, u.totp_enabled_at, `+
+			repository.RolePermissionsExpr("u.role")+`
+    from person.users u where `+where,
+		arg,
+	).Scan(
+		&user.Id,
+		&user.Password,
+		&user.Email,
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.DisabledAt,
+		&user.TotpSecret,
+		&user.TotpEnabledAt,
+		&user.Permissions,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, exception.NotFound("user")
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) getUserByEmail(email string) (*User, error) {
 	var user User
 
 	err := r.DB.QueryRow(
 		context.Background(),
-		"select id, password, email, role from person.users where email = $1",
-		login.Email,
-	).Scan(&user.Id, &user.Password, &user.Email, &user.Role)
+		"select id, first_name, last_name, email, email_verified_at from person.users where email = $1",
+		email,
+	).Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email, &user.EmailVerifiedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, errors.New(strconv.Itoa(http.StatusNotFound))
+			return nil, exception.NotFound("user")
 		}
 		return nil, err
 	}
 
 	return &user, nil
 }
+
+// createToken stores a new token for the purpose and voids the earlier ones, so only the
+// link in the latest email works.
+func (r *UserRepository) createToken(
+	userId int,
+	purpose TokenPurpose,
+	tokenHash string,
+	expiresAt time.Time,
+) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+		_, err := tx.Exec(
+			ctx,
+			"update person.user_token set used_at = $3 where user_id = $1 and purpose = $2 and used_at is null",
+			userId,
+			purpose,
+			time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"insert into person.user_token (user_id, purpose, token_hash, expires_at) values ($1, $2, $3, $4)",
+			userId,
+			purpose,
+			tokenHash,
+			expiresAt.UTC(),
+		)
+		return err
+	})
+}
+
+// useToken uses up an unexpired token for the purpose and returns the user it was issued to.
+func useToken(tx pgx.Tx, purpose TokenPurpose, tokenHash string) (int, error) {
+	now := time.Now().UTC()
+
+	var userId int
+	err := tx.QueryRow(
+		context.Background(),
+		`update person.user_token set used_at = $3
+    where token_hash = $1 and purpose = $2 and used_at is null and expires_at > $3
+    returning user_id`,
+		tokenHash,
+		purpose,
+		now,
+	).Scan(&userId)
+	if err == pgx.ErrNoRows {
+		return 0, exception.Validation("token", "token is invalid or expired")
+	}
+	return userId, err
+}
+
+// resetPassword sets the password of the user the token was issued to and ends all of their
+// sessions, since whoever knew the old password may still be signed in.
+func (r *UserRepository) resetPassword(tokenHash string, passwordHash string) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		userId, err := useToken(tx, PasswordResetToken, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.users set password = $1, updated_at = $2 where id = $3",
+			passwordHash,
+			time.Now(),
+			userId,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.session set revoked_at = current_timestamp where user_id = $1 and revoked_at is null",
+			userId,
+		)
+		return err
+	})
+}
+
+func (r *UserRepository) verifyEmail(tokenHash string) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		userId, err := useToken(tx, EmailVerificationToken, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			context.Background(),
+			"update person.users set email_verified_at = $1 where id = $2 and email_verified_at is null",
+			time.Now(),
+			userId,
+		)
+		return err
+	})
+}
+
+func (r *UserRepository) getProfile(id int) (ProfileResponse, error) {
+	var profile ProfileResponse
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select id, first_name, last_name, email, coalesce(phone_number, ''), role, email_verified_at, created_at,
+    totp_enabled_at is not null
+    from person.users where id = $1`,
+		id,
+	).Scan(
+		&profile.Id,
+		&profile.FirstName,
+		&profile.LastName,
+		&profile.Email,
+		&profile.PhoneNumber,
+		&profile.Role,
+		&profile.EmailVerifiedAt,
+		&profile.CreatedAt,
+		&profile.TwoFactorEnabled,
+	)
+	if err == pgx.ErrNoRows {
+		return profile, exception.NotFound("user", id)
+	}
+	return profile, err
+}
+
+func (r *UserRepository) updateProfile(id int, payload *ProfileUpdateDto) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"update person.users set first_name = $1, last_name = $2, phone_number = $3, updated_at = $4 where id = $5",
+		payload.FirstName,
+		payload.LastName,
+		payload.PhoneNumber,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("user", id)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) getPasswordHash(id int) (string, error) {
+	var password string
+	err := r.DB.QueryRow(
+		context.Background(),
+		"select coalesce(password, '') from person.users where id = $1",
+		id,
+	).Scan(&password)
+	if err == pgx.ErrNoRows {
+		return "", exception.NotFound("user", id)
+	}
+	return password, err
+}
+
+// changePassword sets the password and ends every other session of the user, keeping the
+// one the change was made from.
+func (r *UserRepository) changePassword(id int, sessionId int, passwordHash string) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+		_, err := tx.Exec(
+			ctx,
+			"update person.users set password = $1, updated_at = $2 where id = $3",
+			passwordHash,
+			time.Now(),
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.session set revoked_at = current_timestamp where user_id = $1 and id <> $2 and revoked_at is null",
+			id,
+			sessionId,
+		)
+		return err
+	})
+}
+
+// changeEmail moves the user to a new email, which has to be verified again.
+func (r *UserRepository) changeEmail(id int, email string) error {
+	if err := r.isUserAlreadyRegisted(email); err != nil {
+		return err
+	}
+
+	_, err := r.DB.Exec(
+		context.Background(),
+		"update person.users set email = $1, email_verified_at = null, updated_at = $2 where id = $3",
+		email,
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+// deleteUser removes the user along with their sessions, reviews and lists.
+func (r *UserRepository) deleteUser(id int) error {
+	cmdTag, err := r.DB.Exec(context.Background(), "delete from person.users where id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("user", id)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) getAllUsersPaginated(
+	params web.PaginationParam,
+	filter UserListFilter,
+) (page web.Page[UserListItemResponse], err error) {
+	conditions, args := getUserListFilterConditions(filter)
+
+	keyset, err := web.NewKeyset(params, ListPagination, len(args)+1)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf(
+			`select u.id, u.first_name, u.last_name, u.email, u.role, u.created_at, u.disabled_at, %s
+    from person.users u
+    %s
+    order by %s
+    limit %d`,
+			keyset.SelectColumns(),
+			web.WhereClause(append(conditions, keyset.Condition())...),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append(args, keyset.Args()...)...,
+	)
+	if err != nil {
+		return page, err
+	}
+
+	defer rows.Close()
+
+	res := []UserListItemResponse{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item UserListItemResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(
+			append([]interface{}{
+				&item.Id,
+				&item.FirstName,
+				&item.LastName,
+				&item.Email,
+				&item.Role,
+				&item.CreatedAt,
+				&item.DisabledAt,
+			}, keyDest...)...,
+		)
+		if err != nil {
+			return
+		}
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows(
+			"select count(*) from person.users u "+web.WhereClause(conditions...),
+			args...,
+		)
+	}
+
+	return
+}
+
+// getUserListFilterConditions turns the listing filters into conditions on person.users u,
+// numbering placeholders from $1. The search matches a part of the email or the full name.
+func getUserListFilterConditions(filter UserListFilter) (conditions []string, args []interface{}) {
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(
+			conditions,
+			strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))),
+		)
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		add(
+			`(u.email ilike '%' || $? || '%' or concat(u.first_name, ' ', u.last_name) ilike '%' || $? || '%')`,
+			repository.EscapeLike(search),
+		)
+	}
+	if filter.Role != "" {
+		add("u.role = $?", filter.Role)
+	}
+	switch filter.Status {
+	case "active":
+		conditions = append(conditions, "u.disabled_at is null")
+	case "disabled":
+		conditions = append(conditions, "u.disabled_at is not null")
+	}
+	return
+}
+
+func (r *UserRepository) getUserDetail(id int) (UserDetailResponse, error) {
+	var detail UserDetailResponse
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select id, first_name, last_name, email, coalesce(phone_number, ''), role, email_verified_at, created_at,
+    totp_enabled_at is not null, disabled_at
+    from person.users where id = $1`,
+		id,
+	).Scan(
+		&detail.Id,
+		&detail.FirstName,
+		&detail.LastName,
+		&detail.Email,
+		&detail.PhoneNumber,
+		&detail.Role,
+		&detail.EmailVerifiedAt,
+		&detail.CreatedAt,
+		&detail.TwoFactorEnabled,
+		&detail.DisabledAt,
+	)
+	if err == pgx.ErrNoRows {
+		return detail, exception.NotFound("user", id)
+	}
+	return detail, err
+}
+
+// updateAndEndSessions runs the update on the user with id as $1 and ends all of their
+// sessions, so tokens issued before the change stop working right away.
+func (r *UserRepository) updateAndEndSessions(id int, update string, args ...interface{}) error {
+	err := r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		cmdTag, err := tx.Exec(ctx, update, append([]interface{}{id}, args...)...)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return exception.NotFound("user", id)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.session set revoked_at = current_timestamp where user_id = $1 and revoked_at is null",
+			id,
+		)
+		return err
+	})
+	if repository.IsForeignKeyViolation(err) {
+		return exception.Validation("user", "role does not exist").WithCause(err)
+	}
+	return err
+}
+
+func (r *UserRepository) changeRole(id int, role string) error {
+	return r.updateAndEndSessions(
+		id,
+		"update person.users set role = $2, updated_at = $3 where id = $1",
+		role,
+		time.Now(),
+	)
+}
+
+func (r *UserRepository) disable(id int) error {
+	return r.updateAndEndSessions(
+		id,
+		"update person.users set disabled_at = coalesce(disabled_at, $2) where id = $1",
+		time.Now().UTC(),
+	)
+}
+
+func (r *UserRepository) enable(id int) error {
+	cmdTag, err := r.DB.Exec(context.Background(), "update person.users set disabled_at = null where id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("user", id)
+	}
+
+	return nil
+}
+
+// clearPassword makes the current password stop working, until the user sets a new one
+// through a reset link.
+func (r *UserRepository) clearPassword(id int) error {
+	return r.updateAndEndSessions(
+		id,
+		"update person.users set password = null, updated_at = $2 where id = $1",
+		time.Now(),
+	)
+}
+
+// resetAdmin sets the password of the user with the email and makes them an enabled admin
+// with a verified email, ending their sessions. Their two-factor authentication is turned off,
+// in case the device was lost along with the password.
+func (r *UserRepository) resetAdmin(email string, passwordHash string) error {
+	u, err := r.getUserByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return r.updateAndEndSessions(
+		u.Id,
+		`update person.users set password = $2, role = $3, disabled_at = null,
+    totp_secret = null, totp_enabled_at = null,
+    email_verified_at = coalesce(email_verified_at, $4), updated_at = $4
+    where id = $1`,
+		passwordHash,
+		UserRole.ADMIN,
+		now,
+	)
+}
+
+// findToken returns the user an unexpired token for the purpose was issued to, without
+// using it up. Tokens that failed twoFactorLoginAttempts times are not found.
+func (r *UserRepository) findToken(purpose TokenPurpose, tokenHash string) (int, error) {
+	var userId int
+	err := r.DB.QueryRow(
+		context.Background(),
+		`select user_id from person.user_token
+    where token_hash = $1 and purpose = $2 and used_at is null and expires_at > $3 and failed_attempts < $4`,
+		tokenHash,
+		purpose,
+		time.Now().UTC(),
+		twoFactorLoginAttempts,
+	).Scan(&userId)
+	if err == pgx.ErrNoRows {
+		return 0, exception.Unauthorized("user", "mfa token is invalid or expired")
+	}
+	return userId, err
+}
+
+// recordTokenFailure counts a wrong code sent with the token.
+func (r *UserRepository) recordTokenFailure(tokenHash string) error {
+	_, err := r.DB.Exec(
+		context.Background(),
+		"update person.user_token set failed_attempts = failed_attempts + 1 where token_hash = $1",
+		tokenHash,
+	)
+	return err
+}
+
+// errRecoveryCodeNotFound rolls back useTwoFactorLogin when the recovery code doesn't match.
+var errRecoveryCodeNotFound = errors.New("recovery code not found")
+
+// useTwoFactorLogin uses up the token of the second login step along with the code that
+// finished it, so neither can log in a second time. The code is either the time step of an
+// authenticator code or, when step is 0, the hash of a recovery code. It reports false when
+// the recovery code is not one of the user's unused ones.
+func (r *UserRepository) useTwoFactorLogin(
+	id int,
+	tokenHash string,
+	step int64,
+	recoveryCodeHash string,
+) (bool, error) {
+	err := r.InTransaction(func(tx pgx.Tx) error {
+		if _, err := useToken(tx, TwoFactorLoginToken, tokenHash); err != nil {
+			return exception.Unauthorized("user", "mfa token is invalid or expired").WithCause(err)
+		}
+		if step != 0 {
+			return useTotpStep(tx, id, step)
+		}
+
+		used, err := useRecoveryCode(tx, id, recoveryCodeHash)
+		if err == nil && !used {
+			return errRecoveryCodeNotFound
+		}
+		return err
+	})
+	if errors.Is(err, errRecoveryCodeNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func useTotpStep(tx pgx.Tx, id int, step int64) error {
+	cmdTag, err := tx.Exec(
+		context.Background(),
+		"update person.users set totp_last_step = $2 where id = $1 and totp_last_step < $2",
+		id,
+		step,
+	)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return exception.Forbidden("user", "code was already used")
+	}
+	return nil
+}
+
+// setPendingTotpSecret stores a new secret to enroll with, replacing one that was never
+// confirmed. A user who already has two-factor authentication has to turn it off first.
+func (r *UserRepository) setPendingTotpSecret(id int, secret string) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"update person.users set totp_secret = $2 where id = $1 and totp_enabled_at is null",
+		id,
+		secret,
+	)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return exception.Conflict("two-factor authentication")
+	}
+	return nil
+}
+
+// enableTwoFactor turns on two-factor authentication with the pending secret and stores the
+// hashes of the first recovery codes.
+func (r *UserRepository) enableTwoFactor(id int, codeHashes []string) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(
+			context.Background(),
+			`update person.users set totp_enabled_at = $2, updated_at = $2
+    where id = $1 and totp_secret is not null and totp_enabled_at is null`,
+			id,
+			time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return exception.Conflict("two-factor authentication")
+		}
+		return replaceRecoveryCodes(tx, id, codeHashes)
+	})
+}
+
+// replaceRecoveryCodes voids every recovery code of the user for the new ones.
+func replaceRecoveryCodes(tx pgx.Tx, id int, codeHashes []string) error {
+	ctx := context.Background()
+	_, err := tx.Exec(ctx, "delete from person.recovery_code where user_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"insert into person.recovery_code (user_id, code_hash) select $1, unnest($2::varchar[])",
+		id,
+		codeHashes,
+	)
+	return err
+}
+
+func (r *UserRepository) regenerateRecoveryCodes(id int, codeHashes []string) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		return replaceRecoveryCodes(tx, id, codeHashes)
+	})
+}
+
+// useRecoveryCode uses up an unused recovery code of the user.
+func useRecoveryCode(tx pgx.Tx, id int, codeHash string) (bool, error) {
+	cmdTag, err := tx.Exec(
+		context.Background(),
+		"update person.recovery_code set used_at = $3 where user_id = $1 and code_hash = $2 and used_at is null",
+		id,
+		codeHash,
+		time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// checkSecondFactor uses up the authenticator code with the time step, or the recovery code
+// with the hash when step is 0, outside of a login.
+func (r *UserRepository) checkSecondFactor(id int, step int64, recoveryCodeHash string) (used bool, err error) {
+	err = r.InTransaction(func(tx pgx.Tx) error {
+		if step != 0 {
+			err := useTotpStep(tx, id, step)
+			used = err == nil
+			return err
+		}
+		used, err = useRecoveryCode(tx, id, recoveryCodeHash)
+		return err
+	})
+	return
+}
+
+// disableTwoFactor drops the secret and the recovery codes of the user.
+func (r *UserRepository) disableTwoFactor(id int) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		ctx := context.Background()
+		cmdTag, err := tx.Exec(
+			ctx,
+			"update person.users set totp_secret = null, totp_enabled_at = null, updated_at = $2 where id = $1",
+			id,
+			time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return exception.NotFound("user", id)
+		}
+
+		_, err = tx.Exec(ctx, "delete from person.recovery_code where user_id = $1", id)
+		return err
+	})
+}
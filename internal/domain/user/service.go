@@ -2,42 +2,612 @@ package user
 
 import (
 	"errors"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/mhvn092/movie-go/internal/domain/throttle"
 	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/env"
+	"github.com/mhvn092/movie-go/pkg/exception"
+	"github.com/mhvn092/movie-go/pkg/mailer"
 )
 
 type UserService struct {
-	repo *UserRepository
+	repo     *UserRepository
+	mailer   mailer.Mailer
+	throttle loginThrottle
 }
 
-func NewUserService(repo *UserRepository) *UserService {
-	return &UserService{repo: repo}
+// loginThrottle counts the failed logins, which throttle.ThrottleService does.
+type loginThrottle interface {
+	Check(keys ...throttle.Key) error
+	RecordFailure(keys ...throttle.Key) error
+	Reset(key throttle.Key) error
 }
 
+func NewUserService(
+	repo *UserRepository,
+	mailer mailer.Mailer,
+	throttle *throttle.ThrottleService,
+) *UserService {
+	return &UserService{repo: repo, mailer: mailer, throttle: throttle}
+}
+
+// Register creates the user and mails them a link to verify their email. The user exists
+// either way, so a failing mailer is only logged and they can ask for another link.
 func (s *UserService) Register(u *User, isAdmin bool) error {
 	if isAdmin {
 		u.Role = UserRole.ADMIN
 	}
-	return s.repo.registerUser(u)
+	if err := s.repo.registerUser(u); err != nil {
+		return err
+	}
+
+	if err := s.sendEmailVerification(u); err != nil {
+		log.Printf("could not send the verification email to user %d: %v", u.Id, err)
+	}
+	return nil
 }
 
-func (s *UserService) Login(loginDto *LoginDto) (*User, error) {
+// Login checks the credentials, refusing them while the account or the address they come
+// from is locked out after too many failures. An unknown email fails exactly like a wrong
+// password, in both the response and the time it takes. The failures of the account are only
+// forgotten by StartTwoFactorLogin or FinishTwoFactorLogin, once no second step is left.
+func (s *UserService) Login(loginDto *LoginDto, ip string) (*User, error) {
+	loginDto.Email = normalizeEmail(loginDto.Email)
+	keys := []throttle.Key{throttle.AccountKey(loginDto.Email), throttle.IpKey(ip)}
+
+	if err := s.throttle.Check(keys...); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.checkUser(loginDto)
+	if errors.Is(err, exception.ErrNotFound) {
+		security.ComparePasswordsWithDummy(loginDto.Password)
+		return nil, s.loginFailed(keys, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if err := security.ComparePasswords(user.Password, loginDto.Password); err != nil {
-		return nil, errors.New("email or password is incorrect")
+		return nil, s.loginFailed(keys, err)
+	}
+
+	if user.DisabledAt != nil {
+		return nil, exception.Forbidden("user", "account is disabled")
+	}
+
+	if user.EmailVerifiedAt == nil && env.GetEnv(env.REQUIRE_EMAIL_VERIFICATION) == "true" {
+		return nil, exception.Forbidden("user", "email is not verified yet")
 	}
 
 	return user, nil
 }
 
-func (s *UserService) GenerateToken(u *User) (string, error) {
-	return security.CreateToken(security.UserTokenData{
-		ID:    u.Id,
-		Email: u.Email,
-		Role:  string(u.Role),
-	})
+func (s *UserService) loginFailed(keys []throttle.Key, cause error) error {
+	if err := s.throttle.RecordFailure(keys...); err != nil {
+		return err
+	}
+	return exception.Unauthorized("user", "email or password is incorrect").WithCause(cause)
+}
+
+// Unlock lifts the lockout of the account with the email, so its owner can log in right away.
+func (s *UserService) Unlock(payload *EmailDto) error {
+	return s.throttle.Reset(throttle.AccountKey(normalizeEmail(payload.Email)))
+}
+
+// TokenData is what the access tokens of the user carry.
+func (s *UserService) TokenData(u *User) security.UserTokenData {
+	return security.UserTokenData{
+		ID:          u.Id,
+		Email:       u.Email,
+		Role:        string(u.Role),
+		Permissions: u.Permissions,
+	}
+}
+
+// RequestPasswordReset mails a reset link to the user with the email. Unknown emails are
+// not reported, so the endpoint can't be used to find out who has an account.
+func (s *UserService) RequestPasswordReset(payload *EmailDto) error {
+	u, err := s.repo.getUserByEmail(normalizeEmail(payload.Email))
+	if errors.Is(err, exception.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ttl := security.PasswordResetTTL()
+	token, err := s.createToken(u.Id, PasswordResetToken, ttl)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(passwordResetMessage(u, token, ttl))
+}
+
+// ResetPassword sets a new password with a token from a reset email. The token works once.
+func (s *UserService) ResetPassword(payload *ResetPasswordDto) error {
+	hashedPassword, err := security.HashPassword(strings.TrimSpace(payload.Password))
+	if err != nil {
+		return err
+	}
+	return s.repo.resetPassword(security.HashToken(strings.TrimSpace(payload.Token)), hashedPassword)
+}
+
+// RequestEmailVerification mails a new verification link, unless the email is unknown or
+// already verified, which is not reported for the same reason as in RequestPasswordReset.
+func (s *UserService) RequestEmailVerification(payload *EmailDto) error {
+	u, err := s.repo.getUserByEmail(normalizeEmail(payload.Email))
+	if errors.Is(err, exception.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if u.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.sendEmailVerification(u)
+}
+
+func (s *UserService) VerifyEmail(payload *VerifyEmailDto) error {
+	return s.repo.verifyEmail(security.HashToken(strings.TrimSpace(payload.Token)))
+}
+
+func (s *UserService) sendEmailVerification(u *User) error {
+	ttl := security.EmailVerificationTTL()
+	token, err := s.createToken(u.Id, EmailVerificationToken, ttl)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(emailVerificationMessage(u, token, ttl))
+}
+
+func (s *UserService) createToken(userId int, purpose TokenPurpose, ttl time.Duration) (string, error) {
+	token, hash, err := security.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.createToken(userId, purpose, hash, time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *UserService) GetProfile(userId int) (ProfileResponse, error) {
+	return s.repo.getProfile(userId)
+}
+
+func (s *UserService) UpdateProfile(userId int, payload *ProfileUpdateDto) error {
+	payload.FirstName = strings.TrimSpace(payload.FirstName)
+	payload.LastName = strings.TrimSpace(payload.LastName)
+	payload.PhoneNumber = strings.TrimSpace(payload.PhoneNumber)
+	return s.repo.updateProfile(userId, payload)
+}
+
+// ChangePassword sets a new password once the current one is confirmed. Every other session
+// of the user ends, the one making the change stays.
+func (s *UserService) ChangePassword(userId int, sessionId int, payload *ChangePasswordDto) error {
+	if err := s.confirmPassword(userId, payload.CurrentPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := security.HashPassword(strings.TrimSpace(payload.NewPassword))
+	if err != nil {
+		return err
+	}
+	return s.repo.changePassword(userId, sessionId, hashedPassword)
+}
+
+// ChangeEmail moves the user to a new email once their password is confirmed, and mails
+// a verification link there.
+func (s *UserService) ChangeEmail(userId int, payload *ChangeEmailDto) error {
+	if err := s.confirmPassword(userId, payload.Password); err != nil {
+		return err
+	}
+
+	email := normalizeEmail(payload.Email)
+	if err := s.repo.changeEmail(userId, email); err != nil {
+		return err
+	}
+
+	profile, err := s.repo.getProfile(userId)
+	if err != nil {
+		return err
+	}
+	return s.sendEmailVerification(&User{Id: userId, FirstName: profile.FirstName, Email: email})
+}
+
+// DeleteAccount removes the user and everything they own once their password is confirmed.
+func (s *UserService) DeleteAccount(userId int, payload *DeleteAccountDto) error {
+	if err := s.confirmPassword(userId, payload.Password); err != nil {
+		return err
+	}
+	return s.repo.deleteUser(userId)
+}
+
+func (s *UserService) confirmPassword(userId int, password string) error {
+	hashedPassword, err := s.repo.getPasswordHash(userId)
+	if err != nil {
+		return err
+	}
+
+	if err := security.ComparePasswords(hashedPassword, password); err != nil {
+		return exception.Forbidden("user", "password is incorrect").WithCause(err)
+	}
+	return nil
+}
+
+func (s *UserService) GetAllPaginated(
+	p web.PaginationParam,
+	filter UserListFilter,
+) (web.Page[UserListItemResponse], error) {
+	return s.repo.getAllUsersPaginated(p, filter)
+}
+
+func (s *UserService) GetDetail(id int) (UserDetailResponse, error) {
+	return s.repo.getUserDetail(id)
+}
+
+// ChangeRole gives the user another role. Their sessions end, so the permissions of the old
+// role go with them. actor is the admin making the change, who can't demote themselves.
+func (s *UserService) ChangeRole(actor *security.UserClaims, id int, payload *ChangeRoleDto) error {
+	if actor.Id == id {
+		return exception.Forbidden("user", "you can't change your own role")
+	}
+
+	role := strings.ToLower(strings.TrimSpace(payload.Role))
+	if role == string(UserRole.ADMIN) && !isAdmin(actor) {
+		return exception.Forbidden("user", "only admins can make a user an admin")
+	}
+	if err := s.checkAdminTarget(actor, id); err != nil {
+		return err
+	}
+	return s.repo.changeRole(id, role)
+}
+
+// Disable blocks the user from logging in and ends their sessions until they're enabled again.
+func (s *UserService) Disable(actor *security.UserClaims, id int) error {
+	if actor.Id == id {
+		return exception.Forbidden("user", "you can't disable your own account")
+	}
+	if err := s.checkAdminTarget(actor, id); err != nil {
+		return err
+	}
+	return s.repo.disable(id)
+}
+
+func (s *UserService) Enable(id int) error {
+	return s.repo.enable(id)
+}
+
+func (s *UserService) Delete(actor *security.UserClaims, id int) error {
+	if actor.Id == id {
+		return exception.Forbidden("user", "you can't delete your own account here")
+	}
+	if err := s.checkAdminTarget(actor, id); err != nil {
+		return err
+	}
+	return s.repo.deleteUser(id)
+}
+
+// checkAdminTarget refuses to let anyone but an admin act on an admin, so user:manage alone
+// can't lock the admins out or take over their accounts.
+func (s *UserService) checkAdminTarget(actor *security.UserClaims, id int) error {
+	if isAdmin(actor) {
+		return nil
+	}
+
+	detail, err := s.repo.getUserDetail(id)
+	if err != nil {
+		return err
+	}
+	if detail.Role == string(UserRole.ADMIN) {
+		return exception.Forbidden("user", "only admins can change an admin")
+	}
+	return nil
+}
+
+func isAdmin(claims *security.UserClaims) bool {
+	return claims.ApiKeyId == 0 && claims.Role == string(UserRole.ADMIN)
+}
+
+// ForceResetPassword voids the password of the user, ends their sessions and mails them a
+// link to set a new one.
+func (s *UserService) ForceResetPassword(actor *security.UserClaims, id int) error {
+	if err := s.checkAdminTarget(actor, id); err != nil {
+		return err
+	}
+	if err := s.repo.clearPassword(id); err != nil {
+		return err
+	}
+
+	detail, err := s.repo.getUserDetail(id)
+	if err != nil {
+		return err
+	}
+
+	ttl := security.PasswordResetTTL()
+	token, err := s.createToken(id, PasswordResetToken, ttl)
+	if err != nil {
+		return err
+	}
+	u := &User{Id: id, FirstName: detail.FirstName, Email: detail.Email}
+	return s.mailer.Send(passwordResetMessage(u, token, ttl))
+}
+
+// CreateAdmin adds an admin whose email counts as verified, for setting up an environment
+// from the command line.
+func (s *UserService) CreateAdmin(u *User) error {
+	now := time.Now()
+	u.Role = UserRole.ADMIN
+	u.EmailVerifiedAt = &now
+	return s.repo.registerUser(u)
+}
+
+// ResetAdmin sets a new password for the user with the email and makes sure they are an
+// enabled admin, to recover access from the command line.
+func (s *UserService) ResetAdmin(email string, password string) error {
+	hashedPassword, err := security.HashPassword(strings.TrimSpace(password))
+	if err != nil {
+		return err
+	}
+	return s.repo.resetAdmin(normalizeEmail(email), hashedPassword)
+}
+
+// StartTwoFactorLogin hands out the token for the second login step to a user whose password
+// was correct, when they have two-factor authentication or have to enroll in it first. It
+// returns nil when the password alone logs the user in, which only then is a successful login
+// that forgets the failed attempts of the account.
+func (s *UserService) StartTwoFactorLogin(u *User) (*TwoFactorChallengeResponse, error) {
+	setup := u.needsTwoFactorSetup()
+	if u.TotpEnabledAt == nil && !setup {
+		return nil, s.throttle.Reset(throttle.AccountKey(u.Email))
+	}
+
+	res := &TwoFactorChallengeResponse{TwoFactorRequired: true}
+	if setup {
+		secret, err := security.NewTotpSecret()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.setPendingTotpSecret(u.Id, secret); err != nil {
+			return nil, err
+		}
+		totpSetup := newTotpSetup(u.Email, secret)
+		res.Setup = &totpSetup
+	}
+
+	ttl := security.TwoFactorLoginTTL()
+	token, err := s.createToken(u.Id, TwoFactorLoginToken, ttl)
+	if err != nil {
+		return nil, err
+	}
+	res.MfaToken = token
+	res.ExpiresIn = int(ttl.Seconds())
+	return res, nil
+}
+
+// FinishTwoFactorLogin checks the code of the second login step, which is a code of the
+// authenticator app or an unused recovery code. Wrong codes count towards the lockout of the
+// account like wrong passwords, and after twoFactorLoginAttempts of them the mfa token stops
+// working. Users who enrolled on this login get their recovery codes.
+func (s *UserService) FinishTwoFactorLogin(payload *TwoFactorLoginDto) (*User, []string, error) {
+	tokenHash := security.HashToken(strings.TrimSpace(payload.MfaToken))
+	userId, err := s.repo.findToken(TwoFactorLoginToken, tokenHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := s.repo.getLoginUser(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := throttle.AccountKey(u.Email)
+	if err := s.throttle.Check(key); err != nil {
+		return nil, nil, err
+	}
+
+	if u.DisabledAt != nil {
+		return nil, nil, exception.Forbidden("user", "account is disabled")
+	}
+	if u.TotpSecret == nil {
+		return nil, nil, exception.Unauthorized("user", "mfa token is invalid or expired")
+	}
+
+	enrolling := u.TotpEnabledAt == nil
+	step, ok := security.VerifyTotp(*u.TotpSecret, payload.Code, time.Now())
+	recoveryCodeHash := ""
+	if !ok {
+		// a recovery code can't confirm a secret the user never proved to have
+		if enrolling {
+			return nil, nil, s.loginCodeFailed(key, tokenHash)
+		}
+		recoveryCodeHash = security.HashRecoveryCode(payload.Code)
+	}
+
+	used, err := s.repo.useTwoFactorLogin(u.Id, tokenHash, step, recoveryCodeHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !used {
+		return nil, nil, s.loginCodeFailed(key, tokenHash)
+	}
+
+	var codes []string
+	if enrolling {
+		if codes, err = s.enableTwoFactor(u.Id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := s.throttle.Reset(key); err != nil {
+		return nil, nil, err
+	}
+	return u, codes, nil
+}
+
+func (s *UserService) loginCodeFailed(key throttle.Key, tokenHash string) error {
+	if err := s.repo.recordTokenFailure(tokenHash); err != nil {
+		return err
+	}
+	return s.codeFailed(key)
+}
+
+func (s *UserService) codeFailed(key throttle.Key) error {
+	if err := s.throttle.RecordFailure(key); err != nil {
+		return err
+	}
+	return exception.Unauthorized("user", "code is incorrect")
+}
+
+// SetupTwoFactor starts enrolling the user once their password is confirmed, returning the
+// secret for the authenticator app. Logging in asks for a code only after EnableTwoFactor
+// confirmed one made with it.
+func (s *UserService) SetupTwoFactor(userId int, payload *TwoFactorSetupDto) (TotpSetupResponse, error) {
+	if err := s.confirmPassword(userId, payload.Password); err != nil {
+		return TotpSetupResponse{}, err
+	}
+
+	secret, err := security.NewTotpSecret()
+	if err != nil {
+		return TotpSetupResponse{}, err
+	}
+	if err := s.repo.setPendingTotpSecret(userId, secret); err != nil {
+		return TotpSetupResponse{}, err
+	}
+
+	profile, err := s.repo.getProfile(userId)
+	if err != nil {
+		return TotpSetupResponse{}, err
+	}
+	return newTotpSetup(profile.Email, secret), nil
+}
+
+// EnableTwoFactor turns on two-factor authentication with a code of the secret from
+// SetupTwoFactor and returns the recovery codes, which are shown only this once.
+func (s *UserService) EnableTwoFactor(userId int, payload *TwoFactorCodeDto) (RecoveryCodesResponse, error) {
+	u, err := s.repo.getLoginUser(userId)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	if u.TotpEnabledAt != nil {
+		return RecoveryCodesResponse{}, exception.Conflict("two-factor authentication")
+	}
+	if u.TotpSecret == nil {
+		return RecoveryCodesResponse{}, exception.Validation("two-factor authentication", "start the setup first")
+	}
+
+	step, ok := security.VerifyTotp(*u.TotpSecret, payload.Code, time.Now())
+	if !ok {
+		return RecoveryCodesResponse{}, exception.Forbidden("user", "code is incorrect")
+	}
+	if _, err := s.repo.checkSecondFactor(userId, step, ""); err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	codes, err := s.enableTwoFactor(userId)
+	return RecoveryCodesResponse{RecoveryCodes: codes}, err
+}
+
+// DisableTwoFactor turns off two-factor authentication once the password and a code are
+// confirmed. Admins can't while REQUIRE_ADMIN_2FA is on.
+func (s *UserService) DisableTwoFactor(userId int, payload *PasswordAndCodeDto) error {
+	u, err := s.confirmSecondFactor(userId, payload)
+	if err != nil {
+		return err
+	}
+	if u.Role == UserRole.ADMIN && env.GetEnv(env.REQUIRE_ADMIN_2FA) == "true" {
+		return exception.Forbidden("user", "two-factor authentication is required for admins")
+	}
+	return s.repo.disableTwoFactor(userId)
+}
+
+// RegenerateRecoveryCodes voids the recovery codes of the user for new ones once the
+// password and a code are confirmed.
+func (s *UserService) RegenerateRecoveryCodes(userId int, payload *PasswordAndCodeDto) (RecoveryCodesResponse, error) {
+	if _, err := s.confirmSecondFactor(userId, payload); err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := security.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	if err := s.repo.regenerateRecoveryCodes(userId, hashes); err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// ResetTwoFactor turns off two-factor authentication for a user who lost their device and
+// their recovery codes. actorId is the admin doing it, who can't skip their own codes this way.
+func (s *UserService) ResetTwoFactor(actor *security.UserClaims, id int) error {
+	if actor.Id == id {
+		return exception.Forbidden("user", "you can't reset your own two-factor authentication")
+	}
+	if err := s.checkAdminTarget(actor, id); err != nil {
+		return err
+	}
+	return s.repo.disableTwoFactor(id)
+}
+
+// confirmSecondFactor checks the password and then uses up the code, an authenticator or a
+// recovery code, of a user with two-factor authentication.
+func (s *UserService) confirmSecondFactor(userId int, payload *PasswordAndCodeDto) (*User, error) {
+	if err := s.confirmPassword(userId, payload.Password); err != nil {
+		return nil, err
+	}
+
+	u, err := s.repo.getLoginUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if u.TotpEnabledAt == nil || u.TotpSecret == nil {
+		return nil, exception.Validation("two-factor authentication", "two-factor authentication is not enabled")
+	}
+
+	step, ok := security.VerifyTotp(*u.TotpSecret, payload.Code, time.Now())
+	recoveryCodeHash := ""
+	if !ok {
+		recoveryCodeHash = security.HashRecoveryCode(payload.Code)
+	}
+
+	used, err := s.repo.checkSecondFactor(userId, step, recoveryCodeHash)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, exception.Forbidden("user", "code is incorrect")
+	}
+	return u, nil
+}
+
+func (s *UserService) enableTwoFactor(userId int) ([]string, error) {
+	codes, hashes, err := security.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.enableTwoFactor(userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func newTotpSetup(email string, secret string) TotpSetupResponse {
+	issuer := env.GetEnv(env.TOTP_ISSUER)
+	if issuer == "" {
+		issuer = "movie-go"
+	}
+	return TotpSetupResponse{Secret: secret, OtpauthUri: security.TotpURI(issuer, email, secret)}
 }
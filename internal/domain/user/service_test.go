@@ -0,0 +1,70 @@
+package user
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mhvn092/movie-go/internal/domain/throttle"
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// fakeThrottle counts failures in memory instead of the database.
+type fakeThrottle struct {
+	failures map[throttle.Key]int
+}
+
+func (f *fakeThrottle) Check(keys ...throttle.Key) error {
+	return nil
+}
+
+func (f *fakeThrottle) RecordFailure(keys ...throttle.Key) error {
+	for _, key := range keys {
+		f.failures[key]++
+	}
+	return nil
+}
+
+func (f *fakeThrottle) Reset(key throttle.Key) error {
+	delete(f.failures, key)
+	return nil
+}
+
+func TestPasswordOnlyLoginForgetsFailures(t *testing.T) {
+	limiter := &fakeThrottle{failures: map[throttle.Key]int{}}
+	s := &UserService{throttle: limiter}
+
+	u := &User{Id: 1, Email: "user@example.com", Role: UserRole.NORMAL}
+	account := throttle.AccountKey(u.Email)
+	ip := throttle.IpKey("203.0.113.7")
+	limiter.RecordFailure(account, ip)
+	limiter.RecordFailure(account, ip)
+
+	challenge, err := s.StartTwoFactorLogin(u)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if challenge != nil {
+		t.Fatalf("got a two-factor challenge for a user without two-factor authentication")
+	}
+	if failures := limiter.failures[account]; failures != 0 {
+		t.Errorf("account still has %d failures after logging in", failures)
+	}
+	if failures := limiter.failures[ip]; failures != 2 {
+		t.Errorf("address has %d failures, want the 2 it had", failures)
+	}
+}
+
+func TestOnlyAdminsMakeAdmins(t *testing.T) {
+	s := &UserService{}
+
+	for _, actor := range []*security.UserClaims{
+		{Id: 2, Role: "editor", Permissions: []string{"user:manage"}},
+		{ApiKeyId: 3, Role: "admin", Permissions: []string{"user:manage"}},
+	} {
+		err := s.ChangeRole(actor, 5, &ChangeRoleDto{Role: " Admin "})
+		if !errors.Is(err, exception.ErrForbidden) {
+			t.Errorf("%+v: got error %v, want forbidden", actor, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package watchlist
+
+import "time"
+
+type WatchlistItemResponse struct {
+	MovieId        int       `json:"movie_id"        db:"movie_id"`
+	Title          string    `json:"title"           db:"title"`
+	ProductionYear int       `json:"production_year" db:"production_year"`
+	Position       int       `json:"position"        db:"position"`
+	AddedAt        time.Time `json:"added_at"        db:"created_at"`
+}
+
+type WatchedItemResponse struct {
+	MovieId        int       `json:"movie_id"        db:"movie_id"`
+	Title          string    `json:"title"           db:"title"`
+	ProductionYear int       `json:"production_year" db:"production_year"`
+	WatchedAt      time.Time `json:"watched_at"      db:"watched_at"`
+}
+
+type WatchlistMovePayload struct {
+	Position int `json:"position" validate:"required, is_int, min=1"`
+}
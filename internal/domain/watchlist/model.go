@@ -0,0 +1,17 @@
+package watchlist
+
+import "time"
+
+// WatchlistEntry is a movie the user wants to watch. Position orders the list from 1 without gaps.
+type WatchlistEntry struct {
+	UserId    int       `json:"user_id"  db:"user_id"`
+	MovieId   int       `json:"movie_id" db:"movie_id"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `                db:"created_at"`
+}
+
+type WatchedEntry struct {
+	UserId    int       `json:"user_id"    db:"user_id"`
+	MovieId   int       `json:"movie_id"   db:"movie_id"`
+	WatchedAt time.Time `json:"watched_at" db:"watched_at"`
+}
@@ -0,0 +1,304 @@
+package watchlist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+type WatchlistRepository struct {
+	*repository.BaseRepository
+}
+
+// WatchlistPagination lists the fields a watchlist can be sorted by, in the user's own order by default.
+var WatchlistPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"position": {Expr: "w.position", Type: "integer"},
+		"added_at": {Expr: "w.created_at", Type: "timestamp"},
+		"title":    {Expr: "m.title", Type: "text"},
+		"id":       {Expr: "m.id", Type: "integer"},
+	},
+	DefaultSort: "position",
+}
+
+// WatchedPagination lists the fields the watched history can be sorted by, latest first by default.
+var WatchedPagination = web.PaginationSpec{
+	Columns: map[string]web.Column{
+		"watched_at": {Expr: "w.watched_at", Type: "timestamp"},
+		"title":      {Expr: "m.title", Type: "text"},
+		"id":         {Expr: "m.id", Type: "integer"},
+	},
+	DefaultSort: "-watched_at",
+}
+
+func NewWatchlistRepository(base *repository.BaseRepository) *WatchlistRepository {
+	return &WatchlistRepository{BaseRepository: base}
+}
+
+func (r *WatchlistRepository) getWatchlistPaginated(
+	userId int,
+	params web.PaginationParam,
+) (page web.Page[WatchlistItemResponse], err error) {
+	keyset, err := web.NewKeyset(params, WatchlistPagination, 2)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf(
+			`select m.id, m.title, m.production_year, w.position, w.created_at, %s
+    from person.watchlist w
+    join movie.movie m on m.id = w.movie_id
+    %s
+    order by %s
+    limit %d`,
+			keyset.SelectColumns(),
+			web.WhereClause("w.user_id = $1", keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append([]interface{}{userId}, keyset.Args()...)...,
+	)
+	if err != nil {
+		return page, err
+	}
+
+	defer rows.Close()
+
+	res := []WatchlistItemResponse{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item WatchlistItemResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(
+			append([]interface{}{
+				&item.MovieId,
+				&item.Title,
+				&item.ProductionYear,
+				&item.Position,
+				&item.AddedAt,
+			}, keyDest...)...,
+		)
+		if err != nil {
+			return
+		}
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from person.watchlist where user_id = $1", userId)
+	}
+
+	return
+}
+
+func (r *WatchlistRepository) getWatchedPaginated(
+	userId int,
+	params web.PaginationParam,
+) (page web.Page[WatchedItemResponse], err error) {
+	keyset, err := web.NewKeyset(params, WatchedPagination, 2)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf(
+			`select m.id, m.title, m.production_year, w.watched_at, %s
+    from person.watched w
+    join movie.movie m on m.id = w.movie_id
+    %s
+    order by %s
+    limit %d`,
+			keyset.SelectColumns(),
+			web.WhereClause("w.user_id = $1", keyset.Condition()),
+			keyset.OrderBy(),
+			keyset.FetchLimit(),
+		),
+		append([]interface{}{userId}, keyset.Args()...)...,
+	)
+	if err != nil {
+		return page, err
+	}
+
+	defer rows.Close()
+
+	res := []WatchedItemResponse{}
+	rowKeys := [][]string{}
+
+	for rows.Next() {
+		var item WatchedItemResponse
+		keys, keyDest := keyset.RowKeys()
+		err = rows.Scan(
+			append([]interface{}{&item.MovieId, &item.Title, &item.ProductionYear, &item.WatchedAt}, keyDest...)...,
+		)
+		if err != nil {
+			return
+		}
+		res = append(res, item)
+		rowKeys = append(rowKeys, keys)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	page = web.NewPage(res, rowKeys, params)
+
+	if params.WithTotal {
+		page.Total, err = r.CountRows("select count(*) from person.watched where user_id = $1", userId)
+	}
+
+	return
+}
+
+// inUserTransaction runs fn in a transaction holding a lock on the user's row, so concurrent
+// changes to the same watchlist can't hand out the same position twice.
+func (r *WatchlistRepository) inUserTransaction(userId int, fn func(tx pgx.Tx) error) error {
+	return r.InTransaction(func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), "select id from person.users where id = $1 for update", userId)
+		if err != nil {
+			return fmt.Errorf("failed to lock the user: %w", err)
+		}
+		return fn(tx)
+	})
+}
+
+// addToWatchlist puts the movie at the end of the watchlist. Adding it again changes nothing.
+func (r *WatchlistRepository) addToWatchlist(userId int, movieId int) error {
+	return r.inUserTransaction(userId, func(tx pgx.Tx) error {
+		_, err := tx.Exec(
+			context.Background(),
+			`insert into person.watchlist (user_id, movie_id, position)
+    select $1, $2, coalesce(max(position), 0) + 1 from person.watchlist where user_id = $1
+    on conflict do nothing`,
+			userId,
+			movieId,
+		)
+		if repository.IsForeignKeyViolation(err) {
+			return exception.NotFound("movie", movieId).WithCause(err)
+		}
+		return err
+	})
+}
+
+// removeFromWatchlist deletes the entry and closes the gap it leaves in the positions.
+func (r *WatchlistRepository) removeFromWatchlist(userId int, movieId int) error {
+	return r.inUserTransaction(userId, func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		var position int
+		err := tx.QueryRow(
+			ctx,
+			"delete from person.watchlist where user_id = $1 and movie_id = $2 returning position",
+			userId,
+			movieId,
+		).Scan(&position)
+		if err == pgx.ErrNoRows {
+			return exception.NotFound("watchlist movie", movieId)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.watchlist set position = position - 1 where user_id = $1 and position > $2",
+			userId,
+			position,
+		)
+		return err
+	})
+}
+
+// moveInWatchlist moves the entry to position, shifting the entries in between by one.
+// A position past the end moves it to the end.
+func (r *WatchlistRepository) moveInWatchlist(userId int, movieId int, position int) error {
+	return r.inUserTransaction(userId, func(tx pgx.Tx) error {
+		ctx := context.Background()
+
+		var current, last int
+		err := tx.QueryRow(
+			ctx,
+			`select position, (select max(position) from person.watchlist where user_id = $1)
+    from person.watchlist where user_id = $1 and movie_id = $2`,
+			userId,
+			movieId,
+		).Scan(&current, &last)
+		if err == pgx.ErrNoRows {
+			return exception.NotFound("watchlist movie", movieId)
+		}
+		if err != nil {
+			return err
+		}
+
+		position = min(position, last)
+		if position == current {
+			return nil
+		}
+
+		shift := "update person.watchlist set position = position + 1 where user_id = $1 and position >= $2 and position < $3"
+		if position > current {
+			shift = "update person.watchlist set position = position - 1 where user_id = $1 and position > $3 and position <= $2"
+		}
+		if _, err := tx.Exec(ctx, shift, userId, position, current); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"update person.watchlist set position = $1 where user_id = $2 and movie_id = $3",
+			position,
+			userId,
+			movieId,
+		)
+		return err
+	})
+}
+
+// markWatched records that the user watched the movie now. Marking it again moves it to now.
+func (r *WatchlistRepository) markWatched(userId int, movieId int) error {
+	_, err := r.DB.Exec(
+		context.Background(),
+		`insert into person.watched (user_id, movie_id) values ($1, $2)
+    on conflict (user_id, movie_id) do update set watched_at = current_timestamp`,
+		userId,
+		movieId,
+	)
+	if repository.IsForeignKeyViolation(err) {
+		return exception.NotFound("movie", movieId).WithCause(err)
+	}
+	return err
+}
+
+func (r *WatchlistRepository) unmarkWatched(userId int, movieId int) error {
+	cmdTag, err := r.DB.Exec(
+		context.Background(),
+		"delete from person.watched where user_id = $1 and movie_id = $2",
+		userId,
+		movieId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return exception.NotFound("watched movie", movieId)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package watchlist
+
+import "github.com/mhvn092/movie-go/internal/platform/web"
+
+type WatchlistService struct {
+	repo *WatchlistRepository
+}
+
+func NewWatchlistService(repo *WatchlistRepository) *WatchlistService {
+	return &WatchlistService{repo: repo}
+}
+
+func (s *WatchlistService) GetWatchlistPaginated(
+	userId int,
+	p web.PaginationParam,
+) (web.Page[WatchlistItemResponse], error) {
+	return s.repo.getWatchlistPaginated(userId, p)
+}
+
+func (s *WatchlistService) AddToWatchlist(userId int, movieId int) error {
+	return s.repo.addToWatchlist(userId, movieId)
+}
+
+func (s *WatchlistService) RemoveFromWatchlist(userId int, movieId int) error {
+	return s.repo.removeFromWatchlist(userId, movieId)
+}
+
+func (s *WatchlistService) MoveInWatchlist(userId int, movieId int, payload *WatchlistMovePayload) error {
+	return s.repo.moveInWatchlist(userId, movieId, payload.Position)
+}
+
+func (s *WatchlistService) GetWatchedPaginated(
+	userId int,
+	p web.PaginationParam,
+) (web.Page[WatchedItemResponse], error) {
+	return s.repo.getWatchedPaginated(userId, p)
+}
+
+func (s *WatchlistService) MarkWatched(userId int, movieId int) error {
+	return s.repo.markWatched(userId, movieId)
+}
+
+func (s *WatchlistService) UnmarkWatched(userId int, movieId int) error {
+	return s.repo.unmarkWatched(userId, movieId)
+}
@@ -3,12 +3,14 @@ package config
 import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/mhvn092/movie-go/pkg/mailer"
 	"github.com/mhvn092/movie-go/pkg/router"
 )
 
 type appConfigStruct struct {
-	mux *router.Router
-	db  *pgxpool.Pool
+	mux    *router.Router
+	db     *pgxpool.Pool
+	mailer mailer.Mailer
 }
 
 var appConfig *appConfigStruct
@@ -21,6 +23,10 @@ func GetRouter() *router.Router {
 	return appConfig.mux
 }
 
-func InitializeAppConfig(mux *router.Router, db *pgxpool.Pool) {
-	appConfig = &appConfigStruct{mux, db}
+func GetMailer() mailer.Mailer {
+	return appConfig.mailer
+}
+
+func InitializeAppConfig(mux *router.Router, db *pgxpool.Pool, m mailer.Mailer) {
+	appConfig = &appConfigStruct{mux, db, m}
 }
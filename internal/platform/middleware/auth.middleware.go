@@ -3,71 +3,35 @@ package middleware
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
-
-	"github.com/mhvn092/movie-go/internal/domain/user"
 	"github.com/mhvn092/movie-go/internal/platform/security"
-	"github.com/mhvn092/movie-go/pkg/env"
 	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
-func isAdminAuthorized() Middleware {
-	return authorized(true)
-}
-
 func isUserAuthorized() Middleware {
-	return authorized(false)
+	return authorized(nil)
 }
 
-func authorized(checkAdmin bool) Middleware {
+// authorized lets through requests with a valid, unrevoked token, for which allowed holds
+// when it's given. Routes that require a permission also take an api key instead of a token,
+// the permissions being its scopes. Api keys don't belong to a user, so other routes refuse them.
+func authorized(allowed func(claims *security.UserClaims) bool) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			secret := env.GetEnv(env.JWT_SECRET_KEY)
-
-			authHeader := r.Header.Get("Authorization")
-			if !strings.HasPrefix(authHeader, "Bearer ") {
-				exception.HttpError(
-					errors.New("Missing Authorization header"),
-					w, "Missing Authorization header",
-					http.StatusUnauthorized,
-				)
-				return
-			}
-
-			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-			token, err := jwt.ParseWithClaims(
-				tokenStr,
-				&security.UserClaims{},
-				func(token *jwt.Token) (interface{}, error) {
-					if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-						return nil, fmt.Errorf("unexpected signing method")
-					}
-					return []byte(secret), nil
-				},
-			)
-
-			if err != nil || !token.Valid {
-				exception.HttpError(err, w, "Invalid token", http.StatusUnauthorized)
-				return
+			var claims *security.UserClaims
+			if key := r.Header.Get(security.ApiKeyHeader); key != "" && allowed != nil {
+				claims = apiKeyClaims(w, r, key)
+			} else {
+				claims = bearerClaims(w, r)
 			}
-
-			claims, ok := token.Claims.(*security.UserClaims)
-			if !ok {
-				exception.HttpError(
-					errors.New("Invalid claims"),
-					w,
-					"Invalid token",
-					http.StatusUnauthorized,
-				)
+			if claims == nil {
 				return
 			}
 
-			if checkAdmin && claims.Role != string(user.UserRole.ADMIN) {
-				exception.HttpError(errors.New("Forbidden"), w, "Forbidden", http.StatusForbidden)
+			if allowed != nil && !allowed(claims) {
+				exception.HttpError(errors.New("Forbidden"), w, r, "Forbidden", http.StatusForbidden)
 				return
 			}
 
@@ -76,3 +40,77 @@ func authorized(checkAdmin bool) Middleware {
 		})
 	}
 }
+
+// bearerClaims returns the claims of the bearer token, or writes the error and returns nil.
+func bearerClaims(w http.ResponseWriter, r *http.Request) *security.UserClaims {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		exception.HttpError(
+			errors.New("Missing Authorization header"),
+			w,
+			r,
+			"Missing Authorization header",
+			http.StatusUnauthorized,
+		)
+		return nil
+	}
+
+	claims, err := security.ParseToken(strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		exception.HttpError(err, w, r, "Invalid token", http.StatusUnauthorized)
+		return nil
+	}
+
+	revoked, err := security.IsRevoked(claims)
+	if err != nil {
+		exception.HttpError(err, w, r, "could not check the token", http.StatusInternalServerError)
+		return nil
+	}
+	if revoked {
+		exception.HttpError(
+			errors.New("Revoked token"),
+			w,
+			r,
+			"Token has been revoked",
+			http.StatusUnauthorized,
+		)
+		return nil
+	}
+
+	return claims
+}
+
+// apiKeyClaims returns the claims of the api key, or writes the error and returns nil.
+func apiKeyClaims(w http.ResponseWriter, r *http.Request, key string) *security.UserClaims {
+	claims, err := security.AuthenticateApiKey(key)
+	if err != nil {
+		exception.HttpError(err, w, r, "could not check the api key", http.StatusInternalServerError)
+		return nil
+	}
+	if claims == nil {
+		exception.HttpError(errors.New("Invalid api key"), w, r, "Invalid api key", http.StatusUnauthorized)
+		return nil
+	}
+
+	return claims
+}
+
+// optionalAuth puts the claims on the request when it carries a valid token and lets
+// anonymous requests through, for public routes that show more to signed in users.
+// An invalid, expired or revoked token is treated as no token.
+func optionalAuth() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if strings.HasPrefix(authHeader, "Bearer ") {
+				claims, err := security.ParseToken(strings.TrimPrefix(authHeader, "Bearer "))
+				if err == nil {
+					if revoked, err := security.IsRevoked(claims); err == nil && !revoked {
+						r = r.WithContext(context.WithValue(r.Context(), security.ClaimsKey, claims))
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
 const (
@@ -46,7 +48,8 @@ func prettyJSON(data []byte) string {
 }
 
 func maybePretty(body []byte, contentType string) string {
-	if strings.Contains(contentType, "application/json") {
+	if strings.Contains(contentType, "application/json") ||
+		strings.Contains(contentType, "+json") {
 		return prettyJSON(body)
 	}
 	return string(body)
@@ -74,7 +77,10 @@ func requestLogger() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			reqID := generateRequestID()
+			reqID := r.Header.Get(exception.RequestIdHeader)
+			if reqID == "" {
+				reqID = generateRequestID()
+			}
 
 			var reqBodyRaw []byte
 			if r.Body != nil {
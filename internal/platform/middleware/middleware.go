@@ -1,12 +1,24 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+)
 
 type Middleware func(http.Handler) http.Handler
 
 var (
 	Logger       = requestLogger()
 	AuthUser     = isUserAuthorized()
-	AuthAdmin    = isAdminAuthorized()
+	OptionalAuth = optionalAuth()
 	RecoverPanic = recoverPanic()
+	RequestId    = requestId()
 )
+
+// RequirePermission lets through users whose role grants permission, like "movie:update".
+func RequirePermission(permission string) Middleware {
+	return authorized(func(claims *security.UserClaims) bool {
+		return claims.HasPermission(permission)
+	})
+}
@@ -22,6 +22,7 @@ func recoverPanic() Middleware {
 					exception.HttpError(
 						fmt.Errorf("internal server error"),
 						w,
+						r,
 						"Internal Server Error",
 						http.StatusInternalServerError,
 					)
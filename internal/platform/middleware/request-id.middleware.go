@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+var validRequestId = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// newRequestId creates a random 128 bit id
+func newRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return generateRequestID()
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestId keeps the caller's X-Request-Id when it looks sane, otherwise assigns a new one,
+// and echoes it back so errors and logs can be correlated.
+func requestId() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(exception.RequestIdHeader)
+			if !validRequestId.MatchString(id) {
+				id = newRequestId()
+			}
+
+			r.Header.Set(exception.RequestIdHeader, id)
+			w.Header().Set(exception.RequestIdHeader, id)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -1,7 +1,102 @@
 package repository
 
-import "github.com/jackc/pgx/v5/pgxpool"
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
 
 type BaseRepository struct {
 	DB *pgxpool.Pool
 }
+
+// InTransaction runs fn in a transaction, committing when it returns nil and rolling back otherwise.
+func (r *BaseRepository) InTransaction(fn func(tx pgx.Tx) error) (err error) {
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("rollback failed: %v, original error: %w", rollbackErr, err)
+			}
+		} else {
+			if commitErr := tx.Commit(ctx); commitErr != nil {
+				err = fmt.Errorf("commit failed: %v", commitErr)
+			}
+		}
+	}()
+
+	return fn(tx)
+}
+
+// FindMissingIds returns the ids from the given list that have no row in table.
+func (r *BaseRepository) FindMissingIds(table string, ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.DB.Query(
+		context.Background(),
+		fmt.Sprintf("select id from %s where id = any($1)", table),
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]bool, len(ids))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	missing := []int{}
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// CountRows runs a count query, used for the optional total of a paginated listing.
+func (r *BaseRepository) CountRows(query string, args ...interface{}) (*int, error) {
+	var total int
+	if err := r.DB.QueryRow(context.Background(), query, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+// IsForeignKeyViolation reports whether err is postgres refusing to break a foreign key,
+// e.g. deleting a row other rows still point at.
+func IsForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
+
+// IsUniqueViolation reports whether err is postgres refusing a duplicate of a unique key.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,9 @@
+package repository
+
+// RolePermissionsExpr selects the permissions the role named by roleName grants, as a
+// sorted text array, for the queries issuing tokens.
+func RolePermissionsExpr(roleName string) string {
+	return `array(select rp.permission from person.role_permission rp
+    join person.role ro on ro.id = rp.role_id
+    where ro.name = ` + roleName + ` order by rp.permission)`
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/mhvn092/movie-go/internal/platform/web"
+)
+
+var searchWord = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// ToPrefixTsQuery builds a to_tsquery input from free text. Only letters and digits survive,
+// so operators like & ! | : ( ) in user input can't produce a syntax error. Every word has to
+// match and the last one is matched as a prefix, which suits search-as-you-type.
+// It returns an empty string when nothing searchable is left.
+func ToPrefixTsQuery(term string) string {
+	words := searchWord.FindAllString(strings.ToLower(term), -1)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.Join(words, " & ") + ":*"
+}
+
+// ts_headline copies the text around the matches as it is, markup included, so it marks them
+// with control characters first and Headline escapes the snippet before turning them into <mark>.
+const (
+	headlineStart = "\x01"
+	headlineStop  = "\x02"
+)
+
+// HeadlineOptions marks matches in ts_headline snippets, to be passed through Headline.
+const HeadlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, MinWords=5, MaxWords=20"
+
+var headlineMarker = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// Headline escapes a ts_headline snippet made with HeadlineOptions as HTML, leaving only the
+// <mark> around the matches as markup.
+func Headline(snippet string) string {
+	return headlineMarker.Replace(html.EscapeString(snippet))
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the wildcards of a LIKE pattern so user input only matches literally.
+func EscapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
+// AutocompleteScore ranks labels starting with the typed text ($1, escaped) above labels merely
+// containing it, then by trigram similarity to the raw text ($2).
+func AutocompleteScore(label string) string {
+	return "(" + label + " ilike $1 || '%')::int + word_similarity($2, " + label + ")"
+}
+
+// AutocompleteCondition matches labels containing the typed text or close to it.
+func AutocompleteCondition(label string) string {
+	return "(" + label + " ilike '%' || $1 || '%' or $2 <% " + label + ")"
+}
+
+// QuerySuggestions runs an autocomplete query selecting id, label and score.
+func (r *BaseRepository) QuerySuggestions(
+	suggestionType string,
+	query string,
+	args ...interface{},
+) ([]web.Suggestion, error) {
+	rows, err := r.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []web.Suggestion{}
+	for rows.Next() {
+		item := web.Suggestion{Type: suggestionType}
+		if err := rows.Scan(&item.Id, &item.Label, &item.Score); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,25 @@
+package security
+
+import "errors"
+
+// ApiKeyHeader carries an api key, which service clients send instead of a bearer token.
+const ApiKeyHeader = "X-API-Key"
+
+// ApiKeyAuthenticator resolves an api key into claims granting its scopes, or nil when the
+// key is unknown, expired or revoked.
+type ApiKeyAuthenticator func(key string) (*UserClaims, error)
+
+var apiKeyAuthenticator ApiKeyAuthenticator
+
+// SetApiKeyAuthenticator registers the lookup of api keys, for the same reason as
+// SetRevocationChecker.
+func SetApiKeyAuthenticator(authenticator ApiKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+func AuthenticateApiKey(key string) (*UserClaims, error) {
+	if apiKeyAuthenticator == nil {
+		return nil, errors.New("api keys are not set up")
+	}
+	return apiKeyAuthenticator(key)
+}
@@ -2,6 +2,7 @@ package security
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -11,12 +12,21 @@ type contextKey string
 const ClaimsKey contextKey = "claims"
 
 type UserClaims struct {
-	Id    int    `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	Id          int      `json:"id"`
+	Email       string   `json:"email"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"perms"`
+	SessionId   int      `json:"sid"`
+	// ApiKeyId is set instead of Id when the request authenticated with an api key
+	ApiKeyId int `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// HasPermission tells whether the role of the user granted permission when the token was issued.
+func (c *UserClaims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
 func ClaimsFromContext(r *http.Request) (*UserClaims, bool) {
 	claims, ok := r.Context().Value(ClaimsKey).(*UserClaims)
 	return claims, ok
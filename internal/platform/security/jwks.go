@@ -0,0 +1,52 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JSONWebKey is the public half of a signing key as described in RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// PublicKeys lists every key tokens are verified with, so clients keep verifying tokens
+// of a rotated out key until it is retired. It is empty while tokens are signed with HS256.
+func PublicKeys() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range keySet.keys {
+		jwk := JSONWebKey{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeKeyPart(public.N.Bytes())
+			jwk.E = encodeKeyPart(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encodeKeyPart(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func encodeKeyPart(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
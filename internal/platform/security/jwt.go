@@ -8,19 +8,64 @@ import (
 	"github.com/mhvn092/movie-go/pkg/env"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type UserTokenData struct {
-	ID    int
-	Email string
-	Role  string
+	ID          int
+	Email       string
+	Role        string
+	Permissions []string
+	SessionId   int
+}
+
+type AccessToken struct {
+	Token     string
+	Id        string
+	ExpiresAt time.Time
 }
 
-func CreateToken(data UserTokenData) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// CreateToken signs an access token with the active key of the key set.
+func CreateToken(data UserTokenData) (AccessToken, error) {
+	jti, err := newTokenId()
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL())
+	signed, err := keySet.sign(jwt.MapClaims{
 		"id":    data.ID,
 		"email": data.Email,
 		"role":  data.Role,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(),
+		"perms": data.Permissions,
+		"sid":   data.SessionId,
+		"jti":   jti,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
 	})
-	secretKey := []byte(env.GetEnv(env.JWT_SECRET_KEY))
-	return token.SignedString(secretKey)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	return AccessToken{Token: signed, Id: jti, ExpiresAt: expiresAt}, nil
+}
+
+// AccessTokenTTL is how long an access token lives, ACCESS_TOKEN_TTL or 15 minutes.
+func AccessTokenTTL() time.Duration {
+	return ttlFromEnv(env.ACCESS_TOKEN_TTL, defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL is how long a refresh token can wait to be used, REFRESH_TOKEN_TTL or 30 days.
+func RefreshTokenTTL() time.Duration {
+	return ttlFromEnv(env.REFRESH_TOKEN_TTL, defaultRefreshTokenTTL)
+}
+
+func ttlFromEnv(key string, fallback time.Duration) time.Duration {
+	ttl, err := time.ParseDuration(env.GetEnv(key))
+	if err != nil || ttl <= 0 {
+		return fallback
+	}
+	return ttl
 }
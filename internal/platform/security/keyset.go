@@ -0,0 +1,185 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// signingKey is one key of the key set. Keys without a private half only verify, which is
+// how a rotated out key keeps accepting the tokens it signed until it is retired.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet holds the asymmetric keys tokens are signed and verified with. An empty key set
+// falls back to HS256 with JWT_SECRET_KEY.
+type KeySet struct {
+	keys   map[string]*signingKey
+	active *signingKey
+}
+
+var keySet = &KeySet{keys: map[string]*signingKey{}}
+
+// InitKeySet loads the key set from JWT_KEYS_DIR, if set, and exits when it can't.
+func InitKeySet() {
+	dir := env.GetEnv(env.JWT_KEYS_DIR)
+	if dir == "" {
+		return
+	}
+
+	ks, err := LoadKeySet(dir, env.GetEnv(env.JWT_ACTIVE_KEY_ID))
+	if err != nil {
+		exception.ErrorExit(err, "could not load the jwt keys")
+	}
+	keySet = ks
+}
+
+// LoadKeySet reads every <kid>.pem (private key, signs and verifies) and <kid>.pub.pem
+// (public key, verifies only) in dir. RSA keys sign with RS256, Ed25519 keys with EdDSA.
+// Tokens are signed with the private key activeId, or the last one by name when it's empty,
+// so naming keys by date rotates to the newest key.
+func LoadKeySet(dir string, activeId string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	ks := &KeySet{keys: map[string]*signingKey{}}
+	for _, file := range files {
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if _, exists := ks.keys[key.id]; exists {
+			return nil, fmt.Errorf("%s: duplicate key id %q", file, key.id)
+		}
+		ks.keys[key.id] = key
+
+		if key.private != nil && (activeId == "" || activeId == key.id) {
+			ks.active = key
+		}
+	}
+
+	if ks.active == nil {
+		return nil, fmt.Errorf("no private key to sign with in %s", dir)
+	}
+	return ks, nil
+}
+
+func loadKey(file string) (*signingKey, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	name := filepath.Base(file)
+	if strings.HasSuffix(name, ".pub.pem") {
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newSigningKey(strings.TrimSuffix(name, ".pub.pem"), nil, public)
+	}
+
+	var private interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+	return newSigningKey(strings.TrimSuffix(name, ".pem"), signer, signer.Public())
+}
+
+func newSigningKey(id string, private crypto.Signer, public crypto.PublicKey) (*signingKey, error) {
+	key := &signingKey{id: id, private: private, public: public}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return key, nil
+}
+
+// sign signs the claims with the active key and names it in the kid header.
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	if ks.active == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(env.GetEnv(env.JWT_SECRET_KEY)))
+	}
+
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.id
+	return token.SignedString(ks.active.private)
+}
+
+// verificationKey picks the key for a token by its kid, making sure the token uses the
+// algorithm of that key so an RSA public key can never be used as an HMAC secret.
+func (ks *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	if ks.active == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return []byte(env.GetEnv(env.JWT_SECRET_KEY)), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// ParseToken verifies an access token against the key set and returns its claims.
+func ParseToken(tokenStr string) (*UserClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, keySet.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("Invalid token")
+	}
+
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok {
+		return nil, errors.New("Invalid claims")
+	}
+	return claims, nil
+}
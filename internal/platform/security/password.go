@@ -4,6 +4,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyHash is made up front, so even the first ComparePasswordsWithDummy costs one comparison only.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
 // HashPassword hashes a plain-text password using bcrypt.
 func HashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -17,3 +20,9 @@ func HashPassword(password string) (string, error) {
 func ComparePasswords(hashedPassword, plain string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plain))
 }
+
+// ComparePasswordsWithDummy spends as long as ComparePasswords on a hash no password
+// matches, so a login for an unknown email takes as long as a wrong password.
+func ComparePasswordsWithDummy(plain string) {
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(plain))
+}
@@ -0,0 +1,20 @@
+package security
+
+// RevocationChecker reports whether the token the claims came from was revoked, either by
+// itself or along with its session.
+type RevocationChecker func(claims *UserClaims) (bool, error)
+
+var revocationChecker RevocationChecker
+
+// SetRevocationChecker registers the check the auth middleware runs on every token. It lives
+// here because the middleware can't reach the database without an import cycle.
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
+func IsRevoked(claims *UserClaims) (bool, error) {
+	if revocationChecker == nil {
+		return false, nil
+	}
+	return revocationChecker(claims)
+}
@@ -0,0 +1,59 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+)
+
+const (
+	defaultPasswordResetTTL     = time.Hour
+	defaultEmailVerificationTTL = 48 * time.Hour
+	defaultTwoFactorLoginTTL    = 5 * time.Minute
+)
+
+// NewOpaqueToken returns a random url safe token for the client and the hash to store in its place.
+func NewOpaqueToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes an opaque token for storage and lookup. The tokens are random, so
+// a plain sha256 is enough, unlike passwords.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTokenId() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// PasswordResetTTL is how long a password reset token can wait to be used, PASSWORD_RESET_TTL or an hour.
+func PasswordResetTTL() time.Duration {
+	return ttlFromEnv(env.PASSWORD_RESET_TTL, defaultPasswordResetTTL)
+}
+
+// EmailVerificationTTL is how long an email verification token can wait to be used,
+// EMAIL_VERIFICATION_TTL or 48 hours.
+func EmailVerificationTTL() time.Duration {
+	return ttlFromEnv(env.EMAIL_VERIFICATION_TTL, defaultEmailVerificationTTL)
+}
+
+// TwoFactorLoginTTL is how long the code of the second login step can take to arrive,
+// TWO_FACTOR_LOGIN_TTL or 5 minutes.
+func TwoFactorLoginTTL() time.Duration {
+	return ttlFromEnv(env.TWO_FACTOR_LOGIN_TTL, defaultTwoFactorLoginTTL)
+}
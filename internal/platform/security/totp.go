@@ -0,0 +1,113 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The parameters every authenticator app supports, as in RFC 6238.
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew is how many steps a code may be off either way, for clocks that drift
+	totpSkew = 1
+
+	recoveryCodeLength = 10
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTotpSecret returns a random 160 bit secret in base32, the way authenticator apps take it.
+func NewTotpSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(buf), nil
+}
+
+// TotpURI is the otpauth:// provisioning URI of the secret, for showing as a QR code.
+func TotpURI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	// apps read a + as is, so spaces are escaped the way paths escape them
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// VerifyTotp checks the code against the secret at now and returns the time step it belongs
+// to, so the caller can refuse a step that was already used.
+func VerifyTotp(secret string, code string, now time.Time) (step int64, ok bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := current + offset
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode is the HOTP value of RFC 4226 for the counter.
+func totpCode(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// NewRecoveryCodes returns count random single use codes for the user, formatted as
+// xxxxx-xxxxx, and the hashes to store in their place.
+func NewRecoveryCodes(count int) (codes []string, hashes []string, err error) {
+	buf := make([]byte, 7)
+	for i := 0; i < count; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32NoPadding.EncodeToString(buf))[:recoveryCodeLength]
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a recovery code for storage and lookup, ignoring case, spaces and dashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return HashToken(code)
+}
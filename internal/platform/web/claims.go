@@ -0,0 +1,26 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// GetClaims returns the claims the auth middleware put on the request, or nil after
+// answering 401 when the route isn't behind it.
+func GetClaims(req *http.Request, w http.ResponseWriter) *security.UserClaims {
+	claims, ok := security.ClaimsFromContext(req)
+	if !ok {
+		exception.HttpError(
+			errors.New("No claims on the request"),
+			w,
+			req,
+			"Missing Authorization header",
+			http.StatusUnauthorized,
+		)
+		return nil
+	}
+	return claims
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// trustedProxies are the reverse proxies whose forwarding headers are believed.
+var trustedProxies []*net.IPNet
+
+// InitTrustedProxies reads TRUSTED_PROXIES, the comma separated addresses or CIDR ranges of
+// the proxies in front of the service, and exits when one can't be parsed.
+func InitTrustedProxies() {
+	proxies, err := ParseTrustedProxies(env.GetEnv(env.TRUSTED_PROXIES))
+	exception.ErrorExit(err, "could not read TRUSTED_PROXIES")
+	trustedProxies = proxies
+}
+
+func ParseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", part)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIp is the address the request comes from. Only when it arrives through a trusted
+// proxy is X-Forwarded-For read, right to left up to the first address that isn't a trusted
+// proxy itself, then X-Real-IP. Anyone else could put any address in those headers.
+func ClientIp(req *http.Request) string {
+	remote, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		remote = req.RemoteAddr
+	}
+
+	remoteIp := net.ParseIP(remote)
+	if remoteIp == nil || !isTrustedProxy(remoteIp) {
+		return remote
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		if !isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return remote
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// Cursor remembers where a page ended: the sort it was issued for, the sort values of the
+// boundary row (tie breaker id last), whether it walks backwards and, for search results,
+// the mode they were found in.
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+	Mode     string   `json:"m,omitempty"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorSecret signs the cursors. Without it no cursor is accepted, since anyone could sign one.
+var cursorSecret []byte
+
+// InitCursorSecret reads CURSOR_SECRET_KEY, or JWT_SECRET_KEY when it's not set, and exits
+// when neither is, which happens when tokens are signed with JWT_KEYS_DIR.
+func InitCursorSecret() {
+	secret := env.GetEnv(env.CURSOR_SECRET_KEY)
+	if secret == "" {
+		secret = env.GetEnv(env.JWT_SECRET_KEY)
+	}
+	if secret == "" {
+		exception.ErrorExit(errors.New("no cursor secret"), "CURSOR_SECRET_KEY or JWT_SECRET_KEY has to be set")
+	}
+	cursorSecret = []byte(secret)
+}
+
+func signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// EncodeCursor turns a cursor into an opaque, signed token safe to put in a url.
+func EncodeCursor(c Cursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(payload))
+}
+
+// DecodeCursor verifies the signature of a token produced by EncodeCursor and decodes it.
+func DecodeCursor(token string) (*Cursor, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found || len(cursorSecret) == 0 {
+		return nil, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signCursor(payload)) {
+		return nil, errInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &c, nil
+}
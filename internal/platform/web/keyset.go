@@ -0,0 +1,144 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// Keyset builds the sql fragments for one page of a keyset (seek) paginated query.
+// Placeholders are numbered starting at argStart so the caller can put its own
+// arguments before the cursor values.
+type Keyset struct {
+	param    PaginationParam
+	keys     []SortKey
+	columns  []Column
+	argStart int
+}
+
+func NewKeyset(param PaginationParam, spec PaginationSpec, argStart int) (*Keyset, error) {
+	keys := param.Sort
+	hasTieBreaker := false
+	for _, key := range keys {
+		if key.Field == tieBreakerField {
+			hasTieBreaker = true
+		}
+	}
+	if !hasTieBreaker {
+		keys = append(append([]SortKey{}, keys...), SortKey{Field: tieBreakerField})
+	}
+
+	columns := make([]Column, len(keys))
+	for i, key := range keys {
+		column, ok := spec.Columns[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("no column for sort field %q", key.Field)
+		}
+		columns[i] = column
+	}
+
+	if param.Cursor != nil && len(param.Cursor.Values) != len(keys) {
+		return nil, exception.Validation("pagination", "cursor does not match the requested sort")
+	}
+
+	return &Keyset{param: param, keys: keys, columns: columns, argStart: argStart}, nil
+}
+
+// descending reports the direction the query has to scan in for key i.
+func (k *Keyset) descending(i int) bool {
+	desc := k.keys[i].Desc
+	if k.param.Cursor != nil && k.param.Cursor.Backward {
+		return !desc
+	}
+	return desc
+}
+
+// Condition returns the seek predicate for the cursor, or an empty string on the first page.
+// It expands to (a > $1) OR (a = $1 AND b > $2) ... so mixed sort directions work.
+func (k *Keyset) Condition() string {
+	if k.param.Cursor == nil {
+		return ""
+	}
+
+	ors := make([]string, len(k.columns))
+	for i := range k.columns {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = %s", k.columns[j].Expr, k.placeholder(j)))
+		}
+		op := ">"
+		if k.descending(i) {
+			op = "<"
+		}
+		ands = append(ands, fmt.Sprintf("%s %s %s", k.columns[i].Expr, op, k.placeholder(i)))
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func (k *Keyset) placeholder(i int) string {
+	return fmt.Sprintf("$%d::%s", k.argStart+i, k.columns[i].Type)
+}
+
+// Args returns the cursor values matching the placeholders used by Condition.
+func (k *Keyset) Args() []interface{} {
+	if k.param.Cursor == nil {
+		return nil
+	}
+	args := make([]interface{}, len(k.param.Cursor.Values))
+	for i, value := range k.param.Cursor.Values {
+		args[i] = value
+	}
+	return args
+}
+
+func (k *Keyset) OrderBy() string {
+	parts := make([]string, len(k.columns))
+	for i, column := range k.columns {
+		direction := "ASC"
+		if k.descending(i) {
+			direction = "DESC"
+		}
+		parts[i] = column.Expr + " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SelectColumns is appended to the select list so every row carries its own cursor values.
+func (k *Keyset) SelectColumns() string {
+	parts := make([]string, len(k.columns))
+	for i, column := range k.columns {
+		parts[i] = column.Expr + "::text"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FetchLimit asks for one extra row, which tells us whether another page exists.
+func (k *Keyset) FetchLimit() uint64 {
+	return k.param.Limit + 1
+}
+
+// RowKeys returns a slice for the values selected by SelectColumns and the scan destinations filling it.
+func (k *Keyset) RowKeys() ([]string, []interface{}) {
+	keys := make([]string, len(k.columns))
+	dest := make([]interface{}, len(keys))
+	for i := range keys {
+		dest[i] = &keys[i]
+	}
+	return keys, dest
+}
+
+// WhereClause joins the non empty conditions with AND.
+func WhereClause(conditions ...string) string {
+	var parts []string
+	for _, condition := range conditions {
+		if condition != "" {
+			parts = append(parts, condition)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(parts, " AND ")
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// Page is the envelope every paginated listing responds with.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// NewPage trims the extra row fetched by the keyset query, restores the requested order
+// when walking backwards and issues the cursors for the neighbouring pages.
+// rowKeys holds the values selected by Keyset.SelectColumns for every item.
+func NewPage[T any](items []T, rowKeys [][]string, param PaginationParam) Page[T] {
+	hasMore := uint64(len(items)) > param.Limit
+	if hasMore {
+		items = items[:param.Limit]
+		rowKeys = rowKeys[:param.Limit]
+	}
+
+	backward := param.Cursor != nil && param.Cursor.Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			rowKeys[i], rowKeys[j] = rowKeys[j], rowKeys[i]
+		}
+	}
+
+	page := Page[T]{Items: items}
+	if len(items) == 0 {
+		return page
+	}
+
+	sort := param.SortString()
+	if hasMore || backward {
+		page.NextCursor = EncodeCursor(Cursor{Sort: sort, Values: rowKeys[len(rowKeys)-1], Mode: param.Mode})
+	}
+	if (backward && hasMore) || (!backward && param.Cursor != nil) {
+		page.PrevCursor = EncodeCursor(Cursor{Sort: sort, Values: rowKeys[0], Backward: true, Mode: param.Mode})
+	}
+
+	return page
+}
+
+// WritePage writes the page as json and advertises the neighbouring pages in an RFC 8288 Link header.
+func WritePage[T any](w http.ResponseWriter, req *http.Request, page Page[T]) {
+	WritePageEnvelope(w, req, page, page)
+}
+
+// WritePageEnvelope is WritePage for responses that wrap the page with extra fields.
+func WritePageEnvelope[T any](
+	w http.ResponseWriter,
+	req *http.Request,
+	page Page[T],
+	envelope interface{},
+) {
+	response, err := json.Marshal(envelope)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	var links []string
+	links = append(links, pageLink(req, "", "first"))
+	if page.PrevCursor != "" {
+		links = append(links, pageLink(req, page.PrevCursor, "prev"))
+	}
+	if page.NextCursor != "" {
+		links = append(links, pageLink(req, page.NextCursor, "next"))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// pageLink points at the same listing with only the cursor swapped. RequestURI is used
+// because sub routers strip their prefix from URL.Path.
+func pageLink(req *http.Request, cursor string, rel string) string {
+	path := strings.SplitN(req.RequestURI, "?", 2)[0]
+
+	query := url.Values{}
+	for key, values := range req.URL.Query() {
+		query[key] = values
+	}
+	query.Del("cursor")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	target := path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+	return "<" + target + `>; rel="` + rel + `"`
+}
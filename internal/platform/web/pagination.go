@@ -1,15 +1,172 @@
 package web
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 
-type PaginationParam struct {
-	Limit    uint64
-	CursorID uint64
-}
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
 
 const PaginationKey string = "pagination_param"
 
+const (
+	DefaultPageLimit uint64 = 20
+	MaxPageLimit     uint64 = 100
+)
+
+// tieBreakerField is appended to every sort so rows with equal sort values still have a stable order.
+const tieBreakerField = "id"
+
+// Column is a sortable field: the SQL expression it sorts on and the postgres type
+// its cursor value is cast back to.
+type Column struct {
+	Expr string
+	Type string
+}
+
+// PaginationSpec lists the fields a listing can be sorted by. It must contain an "id" column.
+type PaginationSpec struct {
+	Columns     map[string]Column
+	DefaultSort string
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+type PaginationParam struct {
+	Limit     uint64
+	Sort      []SortKey
+	Cursor    *Cursor
+	WithTotal bool
+	// Mode is the search mode of the page, recorded in the cursors it issues
+	Mode string
+}
+
 func GetPaginationParam(r *http.Request) (PaginationParam, bool) {
 	p, ok := r.Context().Value(PaginationKey).(PaginationParam)
 	return p, ok
 }
+
+// SortString renders the sort back into its query form, e.g. "-production_year,title".
+func (p PaginationParam) SortString() string {
+	return formatSort(p.Sort)
+}
+
+// ParsePaginationParam reads limit, sort, cursor and with_total from the query string.
+func ParsePaginationParam(
+	query url.Values,
+	spec PaginationSpec,
+) (PaginationParam, []exception.FieldError) {
+	var fieldErrors []exception.FieldError
+	param := PaginationParam{Limit: DefaultPageLimit}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit == 0 || limit > MaxPageLimit {
+			fieldErrors = append(fieldErrors, exception.FieldError{
+				Field:   "limit",
+				Message: "must be an int between 1 and " + strconv.FormatUint(MaxPageLimit, 10),
+			})
+		}
+		param.Limit = limit
+	}
+
+	if withTotal := query.Get("with_total"); withTotal != "" {
+		b, err := strconv.ParseBool(withTotal)
+		if err != nil {
+			fieldErrors = append(fieldErrors, exception.FieldError{
+				Field:   "with_total",
+				Message: "must be a boolean",
+			})
+		}
+		param.WithTotal = b
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := DecodeCursor(cursorStr)
+		if err != nil {
+			fieldErrors = append(fieldErrors, exception.FieldError{
+				Field:   "cursor",
+				Message: "is not a valid cursor",
+			})
+		} else {
+			param.Cursor = cursor
+		}
+	}
+
+	sortStr := query.Get("sort")
+	if sortStr == "" && param.Cursor != nil {
+		sortStr = param.Cursor.Sort
+	}
+	if sortStr == "" {
+		sortStr = spec.DefaultSort
+	}
+
+	keys, err := parseSort(sortStr, spec)
+	if err != nil {
+		fieldErrors = append(fieldErrors, *err)
+	}
+	param.Sort = keys
+
+	if param.Cursor != nil && err == nil && param.Cursor.Sort != param.SortString() {
+		fieldErrors = append(fieldErrors, exception.FieldError{
+			Field:   "cursor",
+			Message: "was issued for a different sort",
+		})
+	}
+
+	return param, fieldErrors
+}
+
+// FirstPage returns the first limit items in the default sort of spec, for callers that
+// show a listing without paging through it.
+func FirstPage(spec PaginationSpec, limit uint64) PaginationParam {
+	keys, _ := parseSort(spec.DefaultSort, spec)
+	return PaginationParam{Limit: limit, Sort: keys}
+}
+
+func parseSort(sortStr string, spec PaginationSpec) ([]SortKey, *exception.FieldError) {
+	var keys []SortKey
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(sortStr, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if _, ok := spec.Columns[key.Field]; !ok || seen[key.Field] {
+			return nil, &exception.FieldError{
+				Field:   "sort",
+				Message: "can only contain each of " + strings.Join(spec.fieldNames(), ", ") + " once",
+			}
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func formatSort(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Field
+		if key.Desc {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s PaginationSpec) fieldNames() []string {
+	names := make([]string, 0, len(s.Columns))
+	for name := range s.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -14,6 +14,7 @@ func GetIdFromParam(req *http.Request, w http.ResponseWriter) int {
 		exception.HttpError(
 			errors.New("No Id Provided"),
 			w,
+			req,
 			"No Id Provided",
 			http.StatusBadRequest,
 		)
@@ -21,8 +22,14 @@ func GetIdFromParam(req *http.Request, w http.ResponseWriter) int {
 	}
 
 	id, err := strconv.Atoi(idString)
-	if err != nil {
-		exception.DefaultInternalHttpError(w)
+	if err != nil || id <= 0 {
+		exception.HttpValidationError(
+			errors.New("Invalid Id Provided"),
+			w,
+			req,
+			"Invalid parameter",
+			[]exception.FieldError{{Field: "id", Message: "must be a positive int"}},
+		)
 		return 0
 	}
 
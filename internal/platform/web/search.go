@@ -0,0 +1,54 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+const (
+	SearchModeAuto  = "auto"
+	SearchModeExact = "exact"
+	SearchModeFuzzy = "fuzzy"
+)
+
+// SearchQuery is the query string of the search endpoints. In auto mode a full text search
+// that finds nothing falls back to fuzzy (trigram) matching.
+type SearchQuery struct {
+	Term string `query:"term" validate:"required"`
+	Mode string `query:"mode" validate:"omitempty, one_of=auto exact fuzzy"`
+}
+
+// PageMode returns the mode to search in. A cursor continues in the mode its page was found
+// in, so the next page of an auto search that fell back to fuzzy matching stays fuzzy, and
+// a cursor of another mode than the one asked for is refused.
+func (q SearchQuery) PageMode(resource string, p PaginationParam) (string, error) {
+	if p.Cursor == nil {
+		return q.Mode, nil
+	}
+	if q.Mode == "" || q.Mode == SearchModeAuto || q.Mode == p.Cursor.Mode {
+		return p.Cursor.Mode, nil
+	}
+	return "", exception.InvalidFields(
+		resource,
+		"Invalid parameter",
+		[]exception.FieldError{{Field: "cursor", Message: "was issued for a different search mode"}},
+	)
+}
+
+// Suggestion is one autocomplete entry. It is kept small since it's fetched on every keystroke,
+// the score only decides the order.
+type Suggestion struct {
+	Type  string  `json:"type"`
+	Id    int     `json:"id"`
+	Label string  `json:"label"`
+	Score float32 `json:"-"`
+}
+
+// KeepSearchMode pins the mode the results were produced in on the page links, so paging
+// through a fuzzy fallback doesn't switch back to full text search.
+func KeepSearchMode(req *http.Request, mode string) {
+	query := req.URL.Query()
+	query.Set("mode", mode)
+	req.URL.RawQuery = query.Encode()
+}
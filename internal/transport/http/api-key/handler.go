@@ -0,0 +1,93 @@
+package apikeyhandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/domain/apikey"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getAll(w http.ResponseWriter, req *http.Request) {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
+		return
+	}
+
+	res, err := service.GetAllPaginated(params)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	web.WritePage(w, req, res)
+}
+
+func insert(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload apikey.ApiKeyUpsertPayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	res, err := service.Insert(claims, &payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
+
+func edit(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	var payload apikey.ApiKeyUpsertPayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.Edit(claims, id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func revoke(w http.ResponseWriter, req *http.Request) {
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	if err := service.Revoke(id); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,32 @@
+package apikeyhandler
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/apikey"
+	"github.com/mhvn092/movie-go/internal/platform/config"
+	"github.com/mhvn092/movie-go/internal/platform/middleware"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+var service *apikey.ApiKeyService
+
+func initialize() {
+	db := config.GetDbPool()
+	apiKeyRepo := apikey.NewApiKeyRepository(&repository.BaseRepository{DB: db})
+	service = apikey.NewApiKeyService(apiKeyRepo)
+
+	// the auth middleware accepts api keys through this lookup
+	security.SetApiKeyAuthenticator(service.Authenticate)
+}
+
+func Router() *router.Router {
+	initialize()
+	r := router.NewRouter()
+
+	r.GetWithPagination("/all", apikey.ListPagination, getAll, middleware.RequirePermission("api-key:manage"))
+	r.Post("/create", insert, middleware.RequirePermission("api-key:manage"))
+	r.Put("/update/{id}", edit, middleware.RequirePermission("api-key:manage"))
+	r.Post("/revoke/{id}", revoke, middleware.RequirePermission("api-key:manage"))
+	return r
+}
@@ -1,10 +1,13 @@
 package authhandler
 
 import (
+	"encoding/json"
 	"net/http"
-	"strconv"
 
+	"github.com/mhvn092/movie-go/internal/domain/session"
 	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/internal/platform/web"
 	validator "github.com/mhvn092/movie-go/pkg/Validator"
 	"github.com/mhvn092/movie-go/pkg/exception"
 )
@@ -25,11 +28,7 @@ func signup(w http.ResponseWriter, req *http.Request, isAdmin bool) {
 	}
 
 	if err := service.Register(&payload, isAdmin); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusConflict) {
-			exception.HttpError(err, w, "user already exists", http.StatusConflict)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
@@ -43,17 +42,186 @@ func login(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	u, err := service.Login(&payload)
+	client := clientInfo(req)
+	u, err := service.Login(&payload, client.Ip)
 	if err != nil {
-		exception.HttpError(err, w, err.Error(), http.StatusNotFound)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
-	token, err := service.GenerateToken(u)
+	challenge, err := service.StartTwoFactorLogin(u)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	if challenge != nil {
+		writeJson(w, req, challenge)
+		return
+	}
+
+	res, err := sessionService.Start(service.TokenData(u), client)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	writeJson(w, req, res)
+}
+
+// loginTwoFactor finishes a login with the code asked for by login. Users who enrolled on
+// this login also get their recovery codes.
+func loginTwoFactor(w http.ResponseWriter, req *http.Request) {
+	var payload user.TwoFactorLoginDto
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	u, recoveryCodes, err := service.FinishTwoFactorLogin(&payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	res, err := sessionService.Start(service.TokenData(u), clientInfo(req))
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	writeJson(w, req, struct {
+		session.TokenResponse
+		RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	}{res, recoveryCodes})
+}
+
+func refresh(w http.ResponseWriter, req *http.Request) {
+	var payload session.RefreshPayload
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	res, err := sessionService.Refresh(&payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	writeJson(w, req, res)
+}
+
+func logout(w http.ResponseWriter, req *http.Request) {
+	endSessions(w, req, sessionService.Logout)
+}
+
+func logoutAll(w http.ResponseWriter, req *http.Request) {
+	endSessions(w, req, sessionService.LogoutAll)
+}
+
+func endSessions(w http.ResponseWriter, req *http.Request, end func(*security.UserClaims) error) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	if err := end(claims); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func unlockAccount(w http.ResponseWriter, req *http.Request) {
+	var payload user.EmailDto
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.Unlock(&payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+// forgotPassword answers the same whether or not the email belongs to a user.
+func forgotPassword(w http.ResponseWriter, req *http.Request) {
+	var payload user.EmailDto
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.RequestPasswordReset(&payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func resetPassword(w http.ResponseWriter, req *http.Request) {
+	var payload user.ResetPasswordDto
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.ResetPassword(&payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+// requestEmailVerification answers the same whether or not the email belongs to a user.
+func requestEmailVerification(w http.ResponseWriter, req *http.Request) {
+	var payload user.EmailDto
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.RequestEmailVerification(&payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func verifyEmail(w http.ResponseWriter, req *http.Request) {
+	var payload user.VerifyEmailDto
+
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.VerifyEmail(&payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func clientInfo(req *http.Request) session.ClientInfo {
+	return session.ClientInfo{UserAgent: req.UserAgent(), Ip: web.ClientIp(req)}
+}
+
+func writeJson(w http.ResponseWriter, req *http.Request, res interface{}) {
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
 		return
 	}
 
-	w.Write([]byte(token))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(response)
 }
@@ -1,19 +1,32 @@
 package authhandler
 
 import (
+	"github.com/mhvn092/movie-go/internal/domain/session"
+	"github.com/mhvn092/movie-go/internal/domain/throttle"
 	"github.com/mhvn092/movie-go/internal/domain/user"
 	"github.com/mhvn092/movie-go/internal/platform/config"
 	"github.com/mhvn092/movie-go/internal/platform/middleware"
 	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/internal/platform/security"
 	"github.com/mhvn092/movie-go/pkg/router"
 )
 
-var service *user.UserService
+var (
+	service        *user.UserService
+	sessionService *session.SessionService
+)
 
 func initialize() {
 	db := config.GetDbPool()
 	userRepo := user.NewUserRepository(&repository.BaseRepository{DB: db})
-	service = user.NewUserService(userRepo)
+	sessionRepo := session.NewSessionRepository(&repository.BaseRepository{DB: db})
+	throttleRepo := throttle.NewThrottleRepository(&repository.BaseRepository{DB: db})
+	throttleService := throttle.NewThrottleService(throttleRepo)
+	service = user.NewUserService(userRepo, config.GetMailer(), throttleService)
+	sessionService = session.NewSessionService(sessionRepo)
+
+	// the auth middleware rejects tokens of revoked sessions through this check
+	security.SetRevocationChecker(sessionService.IsRevoked)
 }
 
 func Router() *router.Router {
@@ -21,6 +34,15 @@ func Router() *router.Router {
 	r := router.NewRouter()
 	r.Post("/signup", singnupUser)
 	r.Post("/login", login)
-	r.Post("/add-operator", signupAdmin, middleware.AuthAdmin)
+	r.Post("/login/2fa", loginTwoFactor)
+	r.Post("/refresh", refresh)
+	r.Post("/logout", logout, middleware.AuthUser)
+	r.Post("/logout-all", logoutAll, middleware.AuthUser)
+	r.Post("/add-operator", signupAdmin, middleware.RequirePermission("user:manage"))
+	r.Post("/unlock", unlockAccount, middleware.RequirePermission("user:manage"))
+	r.Post("/password/forgot", forgotPassword)
+	r.Post("/password/reset", resetPassword)
+	r.Post("/verify-email/request", requestEmailVerification)
+	r.Post("/verify-email/confirm", verifyEmail)
 	return r
 }
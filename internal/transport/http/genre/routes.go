@@ -20,9 +20,9 @@ func Router() *router.Router {
 	initialize()
 	r := router.NewRouter()
 
-	r.GetWithPagination("/all", getAll)
-	r.Post("/create", insert, middleware.AuthAdmin)
-	r.Put("/update/{id}", edit, middleware.AuthAdmin)
-	r.Delete("/delete/{id}", delete, middleware.AuthAdmin)
+	r.GetWithPagination("/all", genre.ListPagination, getAll)
+	r.Post("/create", insert, middleware.RequirePermission("genre:create"))
+	r.Put("/update/{id}", edit, middleware.RequirePermission("genre:update"))
+	r.Delete("/delete/{id}", delete, middleware.RequirePermission("genre:delete"))
 	return r
 }
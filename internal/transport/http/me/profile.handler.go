@@ -0,0 +1,109 @@
+package mehandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getProfile(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	res, err := userService.GetProfile(claims.Id)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func updateProfile(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.ProfileUpdateDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := userService.UpdateProfile(claims.Id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func changePassword(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.ChangePasswordDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := userService.ChangePassword(claims.Id, claims.SessionId, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func changeEmail(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.ChangeEmailDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := userService.ChangeEmail(claims.Id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func deleteAccount(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.DeleteAccountDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := userService.DeleteAccount(claims.Id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
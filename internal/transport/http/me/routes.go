@@ -0,0 +1,52 @@
+package mehandler
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/throttle"
+	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/domain/watchlist"
+	"github.com/mhvn092/movie-go/internal/platform/config"
+	"github.com/mhvn092/movie-go/internal/platform/middleware"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+var (
+	watchlistService *watchlist.WatchlistService
+	userService      *user.UserService
+)
+
+func initialize() {
+	db := config.GetDbPool()
+	watchlistRepo := watchlist.NewWatchlistRepository(&repository.BaseRepository{DB: db})
+	userRepo := user.NewUserRepository(&repository.BaseRepository{DB: db})
+	throttleRepo := throttle.NewThrottleRepository(&repository.BaseRepository{DB: db})
+	watchlistService = watchlist.NewWatchlistService(watchlistRepo)
+	throttleService := throttle.NewThrottleService(throttleRepo)
+	userService = user.NewUserService(userRepo, config.GetMailer(), throttleService)
+}
+
+func Router() *router.Router {
+	initialize()
+	r := router.NewRouter()
+
+	r.Get("/profile", getProfile, middleware.AuthUser)
+	r.Put("/profile/update", updateProfile, middleware.AuthUser)
+	r.Post("/password/change", changePassword, middleware.AuthUser)
+	r.Post("/email/change", changeEmail, middleware.AuthUser)
+	r.Delete("/account/delete", deleteAccount, middleware.AuthUser)
+
+	r.Post("/2fa/setup", setupTwoFactor, middleware.AuthUser)
+	r.Post("/2fa/enable", enableTwoFactor, middleware.AuthUser)
+	r.Post("/2fa/disable", disableTwoFactor, middleware.AuthUser)
+	r.Post("/2fa/recovery-codes", regenerateRecoveryCodes, middleware.AuthUser)
+
+	r.GetWithPagination("/watchlist", watchlist.WatchlistPagination, getWatchlist, middleware.AuthUser)
+	r.Post("/watchlist/add/{id}", addToWatchlist, middleware.AuthUser)
+	r.Put("/watchlist/move/{id}", moveInWatchlist, middleware.AuthUser)
+	r.Delete("/watchlist/remove/{id}", removeFromWatchlist, middleware.AuthUser)
+
+	r.GetWithPagination("/watched", watchlist.WatchedPagination, getWatched, middleware.AuthUser)
+	r.Post("/watched/add/{id}", markWatched, middleware.AuthUser)
+	r.Delete("/watched/remove/{id}", unmarkWatched, middleware.AuthUser)
+	return r
+}
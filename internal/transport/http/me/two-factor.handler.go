@@ -0,0 +1,100 @@
+package mehandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func setupTwoFactor(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.TwoFactorSetupDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	res, err := userService.SetupTwoFactor(claims.Id, &payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	writeSecret(w, req, res)
+}
+
+func enableTwoFactor(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.TwoFactorCodeDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	res, err := userService.EnableTwoFactor(claims.Id, &payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	writeSecret(w, req, res)
+}
+
+func disableTwoFactor(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.PasswordAndCodeDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := userService.DisableTwoFactor(claims.Id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func regenerateRecoveryCodes(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload user.PasswordAndCodeDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	res, err := userService.RegenerateRecoveryCodes(claims.Id, &payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	writeSecret(w, req, res)
+}
+
+// writeSecret writes a response holding secrets shown only once, which must not be cached.
+func writeSecret(w http.ResponseWriter, req *http.Request, res interface{}) {
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(response)
+}
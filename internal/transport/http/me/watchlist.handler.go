@@ -0,0 +1,97 @@
+package mehandler
+
+import (
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/domain/watchlist"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getWatchlist(w http.ResponseWriter, req *http.Request) {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
+		return
+	}
+
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	res, err := watchlistService.GetWatchlistPaginated(claims.Id, params)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	web.WritePage(w, req, res)
+}
+
+func addToWatchlist(w http.ResponseWriter, req *http.Request) {
+	changeMovieEntry(w, req, watchlistService.AddToWatchlist)
+}
+
+func removeFromWatchlist(w http.ResponseWriter, req *http.Request) {
+	changeMovieEntry(w, req, watchlistService.RemoveFromWatchlist)
+}
+
+func moveInWatchlist(w http.ResponseWriter, req *http.Request) {
+	var payload watchlist.WatchlistMovePayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	changeMovieEntry(w, req, func(userId int, movieId int) error {
+		return watchlistService.MoveInWatchlist(userId, movieId, &payload)
+	})
+}
+
+func getWatched(w http.ResponseWriter, req *http.Request) {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
+		return
+	}
+
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	res, err := watchlistService.GetWatchedPaginated(claims.Id, params)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	web.WritePage(w, req, res)
+}
+
+func markWatched(w http.ResponseWriter, req *http.Request) {
+	changeMovieEntry(w, req, watchlistService.MarkWatched)
+}
+
+func unmarkWatched(w http.ResponseWriter, req *http.Request) {
+	changeMovieEntry(w, req, watchlistService.UnmarkWatched)
+}
+
+// changeMovieEntry applies change to the movie in the path for the signed in user.
+func changeMovieEntry(w http.ResponseWriter, req *http.Request, change func(userId int, movieId int) error) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	movieId := web.GetIdFromParam(req, w)
+	if movieId == 0 {
+		return
+	}
+
+	if err := change(claims.Id, movieId); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
@@ -2,11 +2,11 @@ package moviehandler
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/mhvn092/movie-go/internal/domain/movie"
+	"github.com/mhvn092/movie-go/internal/platform/security"
 	"github.com/mhvn092/movie-go/internal/platform/web"
 	validator "github.com/mhvn092/movie-go/pkg/Validator"
 	"github.com/mhvn092/movie-go/pkg/exception"
@@ -18,43 +18,44 @@ func getAll(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	res, nextCursor, err := service.GetAllPaginated(params)
-	if err != nil {
-		exception.DefaultInternalHttpError(w)
+	var filter movie.MovieListFilter
+	if validator.QueryHasErrors(req, w, &filter) {
 		return
 	}
 
-	w.Header().Add("X-Next-Cursor", fmt.Sprintf("%d", nextCursor))
-
-	response, err := json.Marshal(res)
+	res, err := service.GetAllPaginated(params, filter)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
-	w.Write([]byte(response))
+	web.WritePage(w, req, res)
 }
 
 func getSearchResults(w http.ResponseWriter, req *http.Request) {
-	searchTerm := req.URL.Query().Get("term")
-	if searchTerm == "" {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
 		return
 	}
 
-	res, err := service.GetSearchResults(searchTerm)
-	if err != nil {
-		fmt.Println("error is : ", err)
-		exception.DefaultInternalHttpError(w)
+	var search web.SearchQuery
+	if validator.QueryHasErrors(req, w, &search) {
 		return
 	}
 
-	response, err := json.Marshal(res)
+	var filter movie.MovieSearchFilter
+	if validator.QueryHasErrors(req, w, &filter) {
+		return
+	}
+
+	res, err := service.GetSearchResults(search, filter, params)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
-	w.Write([]byte(response))
+	web.KeepSearchMode(req, res.Mode)
+	web.WritePageEnvelope(w, req, res.Page, res)
 }
 
 func getDetail(w http.ResponseWriter, req *http.Request) {
@@ -63,15 +64,20 @@ func getDetail(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	res, err := service.GetDetail(id)
+	userId := 0
+	if claims, ok := security.ClaimsFromContext(req); ok {
+		userId = claims.Id
+	}
+
+	res, err := service.GetDetail(id, userId)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
 	response, err := json.Marshal(res)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.DefaultInternalHttpError(w, req)
 		return
 	}
 
@@ -86,11 +92,7 @@ func insert(w http.ResponseWriter, req *http.Request) {
 
 	movieId, err := service.Insert(&payload)
 	if err != nil {
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "some of data sent was not found", http.StatusNotFound)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
@@ -109,16 +111,7 @@ func edit(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := service.Edit(id, &payload); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(
-				err,
-				w,
-				"some of your resources were not found",
-				http.StatusNotFound,
-			)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
@@ -132,11 +125,7 @@ func delete(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := service.Delete(id); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "movie not found", http.StatusNotFound)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
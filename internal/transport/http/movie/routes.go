@@ -29,11 +29,11 @@ func Router() *router.Router {
 	initialize()
 	r := router.NewRouter()
 
-	r.GetWithPagination("/all", getAll)
-	r.Get("/by/{id}", getDetail)
-	r.Get("/search", getSearchResults)
-	r.Post("/create", insert, middleware.AuthAdmin)
-	r.Put("/update/{id}", edit, middleware.AuthAdmin)
-	r.Delete("/delete/{id}", delete, middleware.AuthAdmin)
+	r.GetWithPagination("/all", movie.ListPagination, getAll)
+	r.Get("/by/{id}", getDetail, middleware.OptionalAuth)
+	r.GetWithPagination("/search", movie.SearchPagination, getSearchResults)
+	r.Post("/create", insert, middleware.RequirePermission("movie:create"))
+	r.Put("/update/{id}", edit, middleware.RequirePermission("movie:update"))
+	r.Delete("/delete/{id}", delete, middleware.RequirePermission("movie:delete"))
 	return r
 }
@@ -0,0 +1,95 @@
+package reviewhandler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/mhvn092/movie-go/internal/domain/review"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getMovieReviews(w http.ResponseWriter, req *http.Request) {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
+		return
+	}
+
+	movieId := web.GetIdFromParam(req, w)
+	if movieId == 0 {
+		return
+	}
+
+	res, err := service.GetMovieReviewsPaginated(movieId, params)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	web.WritePage(w, req, res)
+}
+
+func insert(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload review.ReviewCreatePayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	reviewId, err := service.Insert(claims.Id, &payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	w.Write([]byte(strconv.Itoa(reviewId)))
+}
+
+func edit(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	var payload review.ReviewEditPayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.Edit(claims.Id, id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func delete(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	canModerate := claims.HasPermission("review:moderate")
+	if err := service.Delete(claims.Id, canModerate, id); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,28 @@
+package reviewhandler
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/review"
+	"github.com/mhvn092/movie-go/internal/platform/config"
+	"github.com/mhvn092/movie-go/internal/platform/middleware"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+var service *review.ReviewService
+
+func initialize() {
+	db := config.GetDbPool()
+	reviewRepo := review.NewReviewRepository(&repository.BaseRepository{DB: db})
+	service = review.NewReviewService(reviewRepo)
+}
+
+func Router() *router.Router {
+	initialize()
+	r := router.NewRouter()
+
+	r.GetWithPagination("/movie/{id}", review.ListPagination, getMovieReviews)
+	r.Post("/create", insert, middleware.AuthUser)
+	r.Put("/update/{id}", edit, middleware.AuthUser)
+	r.Delete("/delete/{id}", delete, middleware.AuthUser)
+	return r
+}
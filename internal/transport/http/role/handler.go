@@ -0,0 +1,101 @@
+package rolehandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/mhvn092/movie-go/internal/domain/role"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getAll(w http.ResponseWriter, req *http.Request) {
+	res, err := service.GetAll()
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	writeJson(w, req, res)
+}
+
+func getPermissions(w http.ResponseWriter, req *http.Request) {
+	res, err := service.GetPermissions()
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	writeJson(w, req, res)
+}
+
+func insert(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	var payload role.RoleUpsertPayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	roleId, err := service.Insert(claims, &payload)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	w.Write([]byte(strconv.Itoa(roleId)))
+}
+
+func edit(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	var payload role.RoleUpsertPayload
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.Edit(claims, id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func delete(w http.ResponseWriter, req *http.Request) {
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	if err := service.Delete(id); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func writeJson(w http.ResponseWriter, req *http.Request, res interface{}) {
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
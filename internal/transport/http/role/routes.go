@@ -0,0 +1,29 @@
+package rolehandler
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/role"
+	"github.com/mhvn092/movie-go/internal/platform/config"
+	"github.com/mhvn092/movie-go/internal/platform/middleware"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+var service *role.RoleService
+
+func initialize() {
+	db := config.GetDbPool()
+	roleRepo := role.NewRoleRepository(&repository.BaseRepository{DB: db})
+	service = role.NewRoleService(roleRepo)
+}
+
+func Router() *router.Router {
+	initialize()
+	r := router.NewRouter()
+
+	r.Get("/all", getAll, middleware.RequirePermission("role:manage"))
+	r.Get("/permissions", getPermissions, middleware.RequirePermission("role:manage"))
+	r.Post("/create", insert, middleware.RequirePermission("role:manage"))
+	r.Put("/update/{id}", edit, middleware.RequirePermission("role:manage"))
+	r.Delete("/delete/{id}", delete, middleware.RequirePermission("role:manage"))
+	return r
+}
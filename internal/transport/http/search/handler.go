@@ -0,0 +1,53 @@
+package searchhandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/domain/search"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getSearchResults(w http.ResponseWriter, req *http.Request) {
+	var query search.GlobalSearchQuery
+	if validator.QueryHasErrors(req, w, &query) {
+		return
+	}
+
+	res, err := service.Search(query)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	writeJson(w, req, res)
+}
+
+func getAutocomplete(w http.ResponseWriter, req *http.Request) {
+	var query search.AutocompleteQuery
+	if validator.QueryHasErrors(req, w, &query) {
+		return
+	}
+
+	res, err := service.Autocomplete(query)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	// results change with every keystroke, but repeating the same prefix is common
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJson(w, req, res)
+}
+
+func writeJson(w http.ResponseWriter, req *http.Request, res interface{}) {
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
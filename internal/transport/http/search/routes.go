@@ -0,0 +1,36 @@
+package searchhandler
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/genre"
+	"github.com/mhvn092/movie-go/internal/domain/movie"
+	"github.com/mhvn092/movie-go/internal/domain/search"
+	"github.com/mhvn092/movie-go/internal/domain/staff"
+	stafftype "github.com/mhvn092/movie-go/internal/domain/staff-type"
+	"github.com/mhvn092/movie-go/internal/platform/config"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+var service *search.SearchService
+
+func initialize() {
+	db := config.GetDbPool()
+	staffTypeRepo := stafftype.NewStaffTypeRepository(&repository.BaseRepository{DB: db})
+	staffRepo := staff.NewStaffRepository(&repository.BaseRepository{DB: db})
+	genreRepo := genre.NewGenreRepository(&repository.BaseRepository{DB: db})
+	movieRepo := movie.NewMovieRepository(&repository.BaseRepository{DB: db})
+	staffTypeService := stafftype.NewStaffTypeService(staffTypeRepo)
+	staffService := staff.NewStaffService(staffRepo, staffTypeService)
+	genreService := genre.NewGenreService(genreRepo)
+	movieService := movie.NewMovieService(movieRepo, staffTypeService, staffService, genreService)
+	service = search.NewSearchService(movieService, staffService, genreService)
+}
+
+func Router() *router.Router {
+	initialize()
+	r := router.NewRouter()
+
+	r.Get("/{$}", getSearchResults)
+	r.Get("/autocomplete", getAutocomplete)
+	return r
+}
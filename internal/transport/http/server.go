@@ -6,11 +6,18 @@ import (
 
 	"github.com/mhvn092/movie-go/internal/platform/config"
 	"github.com/mhvn092/movie-go/internal/platform/middleware"
+	apikeyhandler "github.com/mhvn092/movie-go/internal/transport/http/api-key"
 	authhandler "github.com/mhvn092/movie-go/internal/transport/http/auth"
 	genrehandler "github.com/mhvn092/movie-go/internal/transport/http/genre"
+	mehandler "github.com/mhvn092/movie-go/internal/transport/http/me"
 	moviehandler "github.com/mhvn092/movie-go/internal/transport/http/movie"
+	reviewhandler "github.com/mhvn092/movie-go/internal/transport/http/review"
+	rolehandler "github.com/mhvn092/movie-go/internal/transport/http/role"
+	searchhandler "github.com/mhvn092/movie-go/internal/transport/http/search"
 	staffhandler "github.com/mhvn092/movie-go/internal/transport/http/staff"
 	stafftypehandler "github.com/mhvn092/movie-go/internal/transport/http/staff-type"
+	usershandler "github.com/mhvn092/movie-go/internal/transport/http/users"
+	wellknownhandler "github.com/mhvn092/movie-go/internal/transport/http/well-known"
 	"github.com/mhvn092/movie-go/pkg/env"
 	"github.com/mhvn092/movie-go/pkg/exception"
 	"github.com/mhvn092/movie-go/pkg/router"
@@ -32,6 +39,7 @@ func InitializeRoutes() {
 	r := config.GetRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.RecoverPanic)
+	r.Use(middleware.RequestId)
 
 	r.Get("/", rootHandler)
 
@@ -40,11 +48,19 @@ func InitializeRoutes() {
 	r.AddSubRoute(getSubRoute("staff-type"), stafftypehandler.Router())
 	r.AddSubRoute(getSubRoute("staff"), staffhandler.Router())
 	r.AddSubRoute(getSubRoute("movie"), moviehandler.Router())
+	r.AddSubRoute(getSubRoute("review"), reviewhandler.Router())
+	r.AddSubRoute(getSubRoute("me"), mehandler.Router())
+	r.AddSubRoute(getSubRoute("search"), searchhandler.Router())
+	r.AddSubRoute(getSubRoute("role"), rolehandler.Router())
+	r.AddSubRoute(getSubRoute("api-key"), apikeyhandler.Router())
+	r.AddSubRoute(getSubRoute("users"), usershandler.Router())
+
+	r.AddSubRoute("/.well-known/", wellknownhandler.Router())
 }
 
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte("Hello World"))
-	exception.HttpError(err, w, "some error exists", 500)
+	exception.HttpError(err, w, r, "some error exists", 500)
 }
 
 func getSubRoute(subRoute string) string {
@@ -1,8 +1,6 @@
 package stafftypehandler
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -18,21 +16,13 @@ func getAll(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	res, nextCursor, err := service.GetAllPaginated(params)
+	res, err := service.GetAllPaginated(params)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
-	w.Header().Add("X-Next-Cursor", fmt.Sprintf("%d", nextCursor))
-
-	response, err := json.Marshal(res)
-	if err != nil {
-		exception.DefaultInternalHttpError(w)
-		return
-	}
-
-	w.Write([]byte(response))
+	web.WritePage(w, req, res)
 }
 
 func insert(w http.ResponseWriter, req *http.Request) {
@@ -43,11 +33,7 @@ func insert(w http.ResponseWriter, req *http.Request) {
 
 	staffTypeId, err := service.Insert(&payload)
 	if err != nil {
-		if err.Error() == strconv.Itoa(http.StatusConflict) {
-			exception.HttpError(err, w, "staff type already exists", http.StatusConflict)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
@@ -66,13 +52,7 @@ func edit(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := service.Edit(id, &payload); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusConflict) {
-			exception.HttpError(err, w, "staff type already exists", http.StatusConflict)
-		} else if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "staff type not found", http.StatusNotFound)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
@@ -86,11 +66,7 @@ func delete(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := service.Delete(id); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "staff type not found", http.StatusNotFound)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
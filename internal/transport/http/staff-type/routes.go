@@ -20,9 +20,9 @@ func Router() *router.Router {
 	initialize()
 	r := router.NewRouter()
 
-	r.GetWithPagination("/all", getAll, middleware.AuthAdmin)
-	r.Post("/create", insert, middleware.AuthAdmin)
-	r.Put("/update/{id}", edit, middleware.AuthAdmin)
-	r.Delete("/delete/{id}", delete, middleware.AuthAdmin)
+	r.GetWithPagination("/all", stafftype.ListPagination, getAll, middleware.RequirePermission("staff-type:read"))
+	r.Post("/create", insert, middleware.RequirePermission("staff-type:create"))
+	r.Put("/update/{id}", edit, middleware.RequirePermission("staff-type:update"))
+	r.Delete("/delete/{id}", delete, middleware.RequirePermission("staff-type:delete"))
 	return r
 }
@@ -2,7 +2,6 @@ package staffhandler
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -18,43 +17,34 @@ func getAll(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	res, nextCursor, err := service.GetAllPaginated(params)
+	res, err := service.GetAllPaginated(params)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
-	w.Header().Add("X-Next-Cursor", fmt.Sprintf("%d", nextCursor))
-
-	response, err := json.Marshal(res)
-	if err != nil {
-		exception.DefaultInternalHttpError(w)
-		return
-	}
-
-	w.Write([]byte(response))
+	web.WritePage(w, req, res)
 }
 
 func getSearchResults(w http.ResponseWriter, req *http.Request) {
-	searchTerm := req.URL.Query().Get("term")
-	if searchTerm == "" {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
 		return
 	}
 
-	res, err := service.GetSearchResults(searchTerm)
-	if err != nil {
-		fmt.Println("error is : ", err)
-		exception.DefaultInternalHttpError(w)
+	var search web.SearchQuery
+	if validator.QueryHasErrors(req, w, &search) {
 		return
 	}
 
-	response, err := json.Marshal(res)
+	res, err := service.GetSearchResults(search, params)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
-	w.Write([]byte(response))
+	web.KeepSearchMode(req, res.Mode)
+	web.WritePageEnvelope(w, req, res.Page, res)
 }
 
 func getDetail(w http.ResponseWriter, req *http.Request) {
@@ -65,13 +55,13 @@ func getDetail(w http.ResponseWriter, req *http.Request) {
 
 	res, err := service.GetDetail(id)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
 	response, err := json.Marshal(res)
 	if err != nil {
-		exception.DefaultInternalHttpError(w)
+		exception.DefaultInternalHttpError(w, req)
 		return
 	}
 
@@ -86,14 +76,7 @@ func insert(w http.ResponseWriter, req *http.Request) {
 
 	staffId, err := service.Insert(&payload)
 	if err != nil {
-		println("err", err.Error())
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "staff not found", http.StatusNotFound)
-		} else if err.Error() == strconv.Itoa(http.StatusConflict) {
-			exception.HttpError(err, w, "staff type already exists", http.StatusConflict)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 
@@ -112,11 +95,7 @@ func edit(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := service.Edit(id, &payload); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "staff not found", http.StatusNotFound)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
@@ -130,11 +109,7 @@ func delete(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := service.Delete(id); err != nil {
-		if err.Error() == strconv.Itoa(http.StatusNotFound) {
-			exception.HttpError(err, w, "staff type not found", http.StatusNotFound)
-		} else {
-			exception.DefaultInternalHttpError(w)
-		}
+		exception.HttpDomainError(err, w, req)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
@@ -23,11 +23,11 @@ func Router() *router.Router {
 	initialize()
 	r := router.NewRouter()
 
-	r.GetWithPagination("/all", getAll)
+	r.GetWithPagination("/all", staff.ListPagination, getAll)
 	r.Get("/by/{id}", getDetail)
-	r.Get("/search", getSearchResults)
-	r.Post("/create", insert, middleware.AuthAdmin)
-	r.Put("/update/{id}", edit, middleware.AuthAdmin)
-	r.Delete("/delete/{id}", delete, middleware.AuthAdmin)
+	r.GetWithPagination("/search", staff.SearchPagination, getSearchResults)
+	r.Post("/create", insert, middleware.RequirePermission("staff:create"))
+	r.Put("/update/{id}", edit, middleware.RequirePermission("staff:update"))
+	r.Delete("/delete/{id}", delete, middleware.RequirePermission("staff:delete"))
 	return r
 }
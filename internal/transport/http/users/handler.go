@@ -0,0 +1,120 @@
+package usershandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/internal/platform/web"
+	validator "github.com/mhvn092/movie-go/pkg/Validator"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getAll(w http.ResponseWriter, req *http.Request) {
+	params, ok := web.GetPaginationParam(req)
+	if !ok {
+		return
+	}
+
+	var filter user.UserListFilter
+	if validator.QueryHasErrors(req, w, &filter) {
+		return
+	}
+
+	res, err := service.GetAllPaginated(params, filter)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	web.WritePage(w, req, res)
+}
+
+func getDetail(w http.ResponseWriter, req *http.Request) {
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	res, err := service.GetDetail(id)
+	if err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+
+	response, err := json.Marshal(res)
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func changeRole(w http.ResponseWriter, req *http.Request) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	var payload user.ChangeRoleDto
+	if validator.JsonBodyHasErrors(req, w, &payload) {
+		return
+	}
+
+	if err := service.ChangeRole(claims, id, &payload); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
+
+func disable(w http.ResponseWriter, req *http.Request) {
+	changeUser(w, req, service.Disable)
+}
+
+func enable(w http.ResponseWriter, req *http.Request) {
+	changeUser(w, req, func(actor *security.UserClaims, id int) error {
+		return service.Enable(id)
+	})
+}
+
+func forceResetPassword(w http.ResponseWriter, req *http.Request) {
+	changeUser(w, req, service.ForceResetPassword)
+}
+
+func resetTwoFactor(w http.ResponseWriter, req *http.Request) {
+	changeUser(w, req, service.ResetTwoFactor)
+}
+
+func delete(w http.ResponseWriter, req *http.Request) {
+	changeUser(w, req, service.Delete)
+}
+
+// changeUser runs change on the user in the path on behalf of the signed in user manager.
+func changeUser(w http.ResponseWriter, req *http.Request, change func(actor *security.UserClaims, id int) error) {
+	claims := web.GetClaims(req, w)
+	if claims == nil {
+		return
+	}
+
+	id := web.GetIdFromParam(req, w)
+	if id == 0 {
+		return
+	}
+
+	if err := change(claims, id); err != nil {
+		exception.HttpDomainError(err, w, req)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Success"))
+}
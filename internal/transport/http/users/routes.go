@@ -0,0 +1,35 @@
+package usershandler
+
+import (
+	"github.com/mhvn092/movie-go/internal/domain/throttle"
+	"github.com/mhvn092/movie-go/internal/domain/user"
+	"github.com/mhvn092/movie-go/internal/platform/config"
+	"github.com/mhvn092/movie-go/internal/platform/middleware"
+	"github.com/mhvn092/movie-go/internal/platform/repository"
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+var service *user.UserService
+
+func initialize() {
+	db := config.GetDbPool()
+	userRepo := user.NewUserRepository(&repository.BaseRepository{DB: db})
+	throttleRepo := throttle.NewThrottleRepository(&repository.BaseRepository{DB: db})
+	throttleService := throttle.NewThrottleService(throttleRepo)
+	service = user.NewUserService(userRepo, config.GetMailer(), throttleService)
+}
+
+func Router() *router.Router {
+	initialize()
+	r := router.NewRouter()
+
+	r.GetWithPagination("/all", user.ListPagination, getAll, middleware.RequirePermission("user:manage"))
+	r.Get("/by/{id}", getDetail, middleware.RequirePermission("user:manage"))
+	r.Put("/role/{id}", changeRole, middleware.RequirePermission("user:manage"))
+	r.Post("/disable/{id}", disable, middleware.RequirePermission("user:manage"))
+	r.Post("/enable/{id}", enable, middleware.RequirePermission("user:manage"))
+	r.Post("/reset-password/{id}", forceResetPassword, middleware.RequirePermission("user:manage"))
+	r.Post("/reset-2fa/{id}", resetTwoFactor, middleware.RequirePermission("user:manage"))
+	r.Delete("/delete/{id}", delete, middleware.RequirePermission("user:manage"))
+	return r
+}
@@ -0,0 +1,22 @@
+package wellknownhandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mhvn092/movie-go/internal/platform/security"
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+func getJwks(w http.ResponseWriter, req *http.Request) {
+	response, err := json.Marshal(security.PublicKeys())
+	if err != nil {
+		exception.DefaultInternalHttpError(w, req)
+		return
+	}
+
+	// keep it short so clients pick up a new key soon after a rotation
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Write(response)
+}
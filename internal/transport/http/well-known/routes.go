@@ -0,0 +1,12 @@
+package wellknownhandler
+
+import (
+	"github.com/mhvn092/movie-go/pkg/router"
+)
+
+func Router() *router.Router {
+	r := router.NewRouter()
+
+	r.Get("/jwks.json", getJwks)
+	return r
+}
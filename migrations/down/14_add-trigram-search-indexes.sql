@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS staff.staff_full_name_trgm_idx;
+
+DROP INDEX IF EXISTS movie.movie_title_trgm_idx;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,10 @@
+ALTER TABLE movie.movie ADD COLUMN genre_id integer;
+
+UPDATE movie.movie m
+SET genre_id = (SELECT min(mg.genre_id) FROM movie.movie_genre mg WHERE mg.movie_id = m.id);
+
+ALTER TABLE movie.movie ALTER COLUMN genre_id SET NOT NULL;
+
+ALTER TABLE movie.movie ADD CONSTRAINT fk_movie_and_genre FOREIGN KEY ("genre_id") REFERENCES "movie"."genre" ("id") ON DELETE NO ACTION ON UPDATE NO ACTION;
+
+DROP TABLE movie.movie_genre;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS review_rating_update ON movie.review;
+
+DROP FUNCTION IF EXISTS movie.review_rating_trigger();
+
+ALTER TABLE movie.movie
+DROP COLUMN rating_sum,
+DROP COLUMN vote_count;
+
+DROP TABLE movie.review;
@@ -0,0 +1,3 @@
+DROP TABLE person.watched;
+
+DROP TABLE person.watchlist;
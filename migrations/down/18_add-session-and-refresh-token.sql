@@ -0,0 +1,5 @@
+DROP TABLE person.revoked_token;
+
+DROP TABLE person.refresh_token;
+
+DROP TABLE person.session;
@@ -0,0 +1,3 @@
+DROP TABLE person.user_token;
+
+ALTER TABLE person.users DROP COLUMN email_verified_at;
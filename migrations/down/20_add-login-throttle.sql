@@ -0,0 +1 @@
+DROP TABLE person.login_throttle;
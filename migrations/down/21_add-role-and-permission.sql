@@ -0,0 +1,15 @@
+CREATE TYPE person.roles
+AS ENUM('admin', 'normal');
+
+ALTER TABLE person.users
+DROP CONSTRAINT fk_users_and_role,
+ALTER COLUMN role DROP NOT NULL,
+ALTER COLUMN role DROP DEFAULT,
+ALTER COLUMN role TYPE person.roles USING (CASE WHEN role = 'admin' THEN 'admin' ELSE 'normal' END)::person.roles,
+ALTER COLUMN role SET DEFAULT 'normal';
+
+DROP TABLE person.role_permission;
+
+DROP TABLE person.permission;
+
+DROP TABLE person.role;
@@ -0,0 +1,3 @@
+DELETE FROM person.permission WHERE name = 'api-key:manage';
+
+DROP TABLE person.api_key;
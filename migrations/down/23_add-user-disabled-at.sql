@@ -0,0 +1 @@
+ALTER TABLE person.users DROP COLUMN disabled_at;
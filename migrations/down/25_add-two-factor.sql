@@ -0,0 +1,12 @@
+DROP TABLE person.recovery_code;
+
+DELETE FROM person.user_token WHERE purpose = 'two_factor_login';
+
+ALTER TABLE person.user_token
+  DROP CONSTRAINT chk_user_token_purpose,
+  ADD CONSTRAINT chk_user_token_purpose CHECK (purpose IN ('password_reset', 'email_verification')) NOT VALID;
+
+ALTER TABLE person.users
+  DROP COLUMN totp_secret,
+  DROP COLUMN totp_enabled_at,
+  DROP COLUMN totp_last_step;
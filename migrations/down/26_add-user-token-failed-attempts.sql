@@ -0,0 +1,2 @@
+ALTER TABLE person.user_token
+  DROP COLUMN failed_attempts;
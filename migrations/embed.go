@@ -0,0 +1,8 @@
+// Package migrations embeds the up and down migrations, so a binary can apply them wherever
+// it runs.
+package migrations
+
+import "embed"
+
+//go:embed up/*.sql down/*.sql
+var FS embed.FS
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS movie_title_trgm_idx ON movie.movie USING GIN (title gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS staff_full_name_trgm_idx ON staff.staff USING GIN ((first_name || ' ' || last_name) gin_trgm_ops);
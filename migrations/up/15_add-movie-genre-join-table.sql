@@ -0,0 +1,16 @@
+CREATE TABLE movie.movie_genre (
+                             movie_id integer NOT NULL,
+                             genre_id integer NOT NULL,
+                             CONSTRAINT pk_movie_genre PRIMARY KEY ("movie_id", "genre_id"),
+                             constraint fk_movie_genre_and_movie FOREIGN KEY ("movie_id") REFERENCES "movie"."movie" ("id") ON DELETE NO ACTION ON UPDATE NO ACTION,
+                             constraint fk_movie_genre_and_genre FOREIGN KEY ("genre_id") REFERENCES "movie"."genre" ("id") ON DELETE NO ACTION ON UPDATE NO ACTION
+);
+
+CREATE INDEX movie_genre_genre_id_idx ON movie.movie_genre (genre_id);
+
+INSERT INTO movie.movie_genre (movie_id, genre_id)
+SELECT id, genre_id FROM movie.movie;
+
+ALTER TABLE movie.movie DROP CONSTRAINT fk_movie_and_genre;
+
+ALTER TABLE movie.movie DROP COLUMN genre_id;
@@ -0,0 +1,43 @@
+CREATE TABLE movie.review (
+                             id SERIAL PRIMARY KEY,
+                             movie_id integer NOT NULL,
+                             user_id integer NOT NULL,
+                             rating smallint NOT NULL,
+                             body text,
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             updated_at TIMESTAMP,
+                             CONSTRAINT uq_review_movie_and_user UNIQUE ("movie_id", "user_id"),
+                             CONSTRAINT ck_review_rating CHECK (rating BETWEEN 1 AND 10),
+                             constraint fk_review_and_movie FOREIGN KEY ("movie_id") REFERENCES "movie"."movie" ("id") ON DELETE CASCADE ON UPDATE NO ACTION,
+                             constraint fk_review_and_user FOREIGN KEY ("user_id") REFERENCES "person"."users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+CREATE INDEX review_movie_id_created_at_idx ON movie.review (movie_id, created_at);
+
+ALTER TABLE movie.movie
+ADD COLUMN rating_sum integer NOT NULL DEFAULT 0,
+ADD COLUMN vote_count integer NOT NULL DEFAULT 0;
+
+-- delimiter //
+CREATE OR REPLACE FUNCTION movie.review_rating_trigger() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    UPDATE movie.movie
+    SET rating_sum = rating_sum - OLD.rating, vote_count = vote_count - 1
+    WHERE id = OLD.movie_id;
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    UPDATE movie.movie
+    SET rating_sum = rating_sum + NEW.rating, vote_count = vote_count + 1
+    WHERE id = NEW.movie_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql//
+-- delimiter ;
+
+CREATE TRIGGER review_rating_update
+AFTER INSERT OR DELETE OR UPDATE OF rating, movie_id
+ON movie.review
+FOR EACH ROW
+EXECUTE FUNCTION movie.review_rating_trigger();
@@ -0,0 +1,22 @@
+CREATE TABLE person.watchlist (
+                             user_id integer NOT NULL,
+                             movie_id integer NOT NULL,
+                             position integer NOT NULL,
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             CONSTRAINT pk_watchlist PRIMARY KEY ("user_id", "movie_id"),
+                             constraint fk_watchlist_and_user FOREIGN KEY ("user_id") REFERENCES "person"."users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION,
+                             constraint fk_watchlist_and_movie FOREIGN KEY ("movie_id") REFERENCES "movie"."movie" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+CREATE INDEX watchlist_user_id_position_idx ON person.watchlist (user_id, position);
+
+CREATE TABLE person.watched (
+                             user_id integer NOT NULL,
+                             movie_id integer NOT NULL,
+                             watched_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             CONSTRAINT pk_watched PRIMARY KEY ("user_id", "movie_id"),
+                             constraint fk_watched_and_user FOREIGN KEY ("user_id") REFERENCES "person"."users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION,
+                             constraint fk_watched_and_movie FOREIGN KEY ("movie_id") REFERENCES "movie"."movie" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+CREATE INDEX watched_user_id_watched_at_idx ON person.watched (user_id, watched_at);
@@ -0,0 +1,30 @@
+CREATE TABLE person.session (
+                             id SERIAL PRIMARY KEY,
+                             user_id integer NOT NULL,
+                             user_agent VARCHAR(255),
+                             ip VARCHAR(64),
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             last_used_at TIMESTAMP,
+                             revoked_at TIMESTAMP,
+                             constraint fk_session_and_user FOREIGN KEY ("user_id") REFERENCES "person"."users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+CREATE INDEX session_user_id_idx ON person.session (user_id);
+
+CREATE TABLE person.refresh_token (
+                             id SERIAL PRIMARY KEY,
+                             session_id integer NOT NULL,
+                             token_hash VARCHAR(64) NOT NULL,
+                             expires_at TIMESTAMP NOT NULL,
+                             used_at TIMESTAMP,
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             CONSTRAINT uq_refresh_token_hash UNIQUE ("token_hash"),
+                             constraint fk_refresh_token_and_session FOREIGN KEY ("session_id") REFERENCES "person"."session" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+CREATE INDEX refresh_token_session_id_idx ON person.refresh_token (session_id);
+
+CREATE TABLE person.revoked_token (
+                             jti VARCHAR(64) PRIMARY KEY,
+                             expires_at TIMESTAMP NOT NULL
+);
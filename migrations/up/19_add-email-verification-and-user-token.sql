@@ -0,0 +1,18 @@
+ALTER TABLE person.users ADD COLUMN email_verified_at TIMESTAMP;
+
+UPDATE person.users SET email_verified_at = created_at;
+
+CREATE TABLE person.user_token (
+                             id SERIAL PRIMARY KEY,
+                             user_id integer NOT NULL,
+                             purpose VARCHAR(32) NOT NULL,
+                             token_hash VARCHAR(64) NOT NULL,
+                             expires_at TIMESTAMP NOT NULL,
+                             used_at TIMESTAMP,
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             CONSTRAINT uq_user_token_hash UNIQUE ("token_hash"),
+                             CONSTRAINT chk_user_token_purpose CHECK (purpose IN ('password_reset', 'email_verification')),
+                             constraint fk_user_token_and_user FOREIGN KEY ("user_id") REFERENCES "person"."users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+CREATE INDEX user_token_user_id_idx ON person.user_token (user_id, purpose);
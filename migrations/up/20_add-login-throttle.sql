@@ -0,0 +1,8 @@
+CREATE TABLE person.login_throttle (
+                             kind VARCHAR(16) NOT NULL,
+                             subject VARCHAR(255) NOT NULL,
+                             failures integer NOT NULL DEFAULT 0,
+                             last_failed_at TIMESTAMP NOT NULL,
+                             locked_until TIMESTAMP,
+                             PRIMARY KEY (kind, subject)
+);
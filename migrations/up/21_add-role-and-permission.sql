@@ -0,0 +1,66 @@
+CREATE TABLE person.role (
+                             id SERIAL PRIMARY KEY,
+                             name VARCHAR(32) NOT NULL,
+                             description VARCHAR(255),
+                             CONSTRAINT uq_role_name UNIQUE ("name")
+);
+
+CREATE TABLE person.permission (
+                             name VARCHAR(64) PRIMARY KEY,
+                             description VARCHAR(255)
+);
+
+CREATE TABLE person.role_permission (
+                             role_id integer NOT NULL,
+                             permission VARCHAR(64) NOT NULL,
+                             CONSTRAINT pk_role_permission PRIMARY KEY ("role_id", "permission"),
+                             constraint fk_role_permission_and_role FOREIGN KEY ("role_id") REFERENCES "person"."role" ("id") ON DELETE CASCADE ON UPDATE NO ACTION,
+                             constraint fk_role_permission_and_permission FOREIGN KEY ("permission") REFERENCES "person"."permission" ("name") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+INSERT INTO person.permission (name, description) VALUES
+('movie:create', 'Create movies'),
+('movie:update', 'Edit movies'),
+('movie:delete', 'Delete movies'),
+('staff:create', 'Create staff'),
+('staff:update', 'Edit staff'),
+('staff:delete', 'Delete staff'),
+('staff-type:read', 'List staff types'),
+('staff-type:create', 'Create staff types'),
+('staff-type:update', 'Edit staff types'),
+('staff-type:delete', 'Delete staff types'),
+('genre:create', 'Create genres'),
+('genre:update', 'Edit genres'),
+('genre:delete', 'Delete genres'),
+('review:moderate', 'Delete the reviews of other users'),
+('user:manage', 'Create operators and manage users'),
+('role:manage', 'Manage roles and their permissions');
+
+INSERT INTO person.role (name, description) VALUES
+('admin', 'Can do everything'),
+('normal', 'A signed up user'),
+('editor', 'Edits the catalog of movies, staff and genres'),
+('moderator', 'Moderates reviews'),
+('viewer', 'Can only browse');
+
+INSERT INTO person.role_permission (role_id, permission)
+SELECT r.id, p.name FROM person.role r CROSS JOIN person.permission p WHERE r.name = 'admin';
+
+INSERT INTO person.role_permission (role_id, permission)
+SELECT r.id, p.name FROM person.role r CROSS JOIN person.permission p
+WHERE r.name = 'editor' AND p.name IN (
+'movie:create', 'movie:update', 'staff:create', 'staff:update', 'staff-type:read',
+'staff-type:create', 'staff-type:update', 'genre:create', 'genre:update'
+);
+
+INSERT INTO person.role_permission (role_id, permission)
+SELECT r.id, 'review:moderate' FROM person.role r WHERE r.name = 'moderator';
+
+ALTER TABLE person.users
+ALTER COLUMN role DROP DEFAULT,
+ALTER COLUMN role TYPE VARCHAR(32) USING coalesce(role::text, 'normal'),
+ALTER COLUMN role SET DEFAULT 'normal',
+ALTER COLUMN role SET NOT NULL,
+ADD constraint fk_users_and_role FOREIGN KEY ("role") REFERENCES "person"."role" ("name") ON DELETE NO ACTION ON UPDATE CASCADE;
+
+DROP TYPE person.roles;
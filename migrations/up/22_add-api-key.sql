@@ -0,0 +1,20 @@
+CREATE TABLE person.api_key (
+                             id SERIAL PRIMARY KEY,
+                             name VARCHAR(255) NOT NULL,
+                             prefix VARCHAR(16) NOT NULL,
+                             key_hash VARCHAR(64) NOT NULL,
+                             scopes VARCHAR(64)[] NOT NULL DEFAULT '{}',
+                             created_by integer,
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             expires_at TIMESTAMP,
+                             revoked_at TIMESTAMP,
+                             last_used_at TIMESTAMP,
+                             usage_count bigint NOT NULL DEFAULT 0,
+                             CONSTRAINT uq_api_key_hash UNIQUE ("key_hash"),
+                             constraint fk_api_key_and_user FOREIGN KEY ("created_by") REFERENCES "person"."users" ("id") ON DELETE SET NULL ON UPDATE NO ACTION
+);
+
+INSERT INTO person.permission (name, description) VALUES ('api-key:manage', 'Manage api keys');
+
+INSERT INTO person.role_permission (role_id, permission)
+SELECT id, 'api-key:manage' FROM person.role WHERE name = 'admin';
@@ -0,0 +1 @@
+ALTER TABLE person.users ADD COLUMN disabled_at TIMESTAMP;
@@ -0,0 +1,2 @@
+DELETE FROM person.users
+  WHERE email = 'admin@gmail.com' AND password = '$2a$12$Bgt575PbYiaHS7Nb4AZHX.3aUuQReKTFLNitaZeI/y/8ABe3zQMOu';
@@ -0,0 +1,18 @@
+ALTER TABLE person.users
+  ADD COLUMN totp_secret VARCHAR(64),
+  ADD COLUMN totp_enabled_at TIMESTAMP,
+  ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE person.recovery_code (
+                             id SERIAL PRIMARY KEY,
+                             user_id integer NOT NULL,
+                             code_hash VARCHAR(64) NOT NULL,
+                             used_at TIMESTAMP,
+                             created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+                             CONSTRAINT uq_recovery_code_user_and_hash UNIQUE ("user_id", "code_hash"),
+                             constraint fk_recovery_code_and_user FOREIGN KEY ("user_id") REFERENCES "person"."users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION
+);
+
+ALTER TABLE person.user_token
+  DROP CONSTRAINT chk_user_token_purpose,
+  ADD CONSTRAINT chk_user_token_purpose CHECK (purpose IN ('password_reset', 'email_verification', 'two_factor_login'));
@@ -0,0 +1,2 @@
+ALTER TABLE person.user_token
+  ADD COLUMN failed_attempts integer NOT NULL DEFAULT 0;
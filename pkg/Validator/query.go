@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mhvn092/movie-go/pkg/exception"
+)
+
+// bindQuery fills the fields of payload tagged with `query:"name"` from the query string.
+func bindQuery(req *http.Request, payload interface{}) []exception.FieldError {
+	var errors []exception.FieldError
+	val := reflect.ValueOf(payload).Elem()
+	typ := val.Type()
+	query := req.URL.Query()
+
+	for i := 0; i < val.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("query")
+		raw := strings.TrimSpace(query.Get(name))
+		if name == "" || raw == "" {
+			continue
+		}
+
+		value := val.Field(i)
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				errors = append(errors, exception.FieldError{Field: name, Message: "must be an int"})
+				continue
+			}
+			value.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				errors = append(errors, exception.FieldError{Field: name, Message: "must be a boolean"})
+				continue
+			}
+			value.SetBool(b)
+		}
+	}
+
+	return errors
+}
+
+// QueryHasErrors binds the query string into payload and validates it like a json body.
+func QueryHasErrors(req *http.Request, w http.ResponseWriter, payload interface{}) bool {
+	validationErrors := bindQuery(req, payload)
+	if validationErrors == nil {
+		validationErrors = validateInterface(payload)
+	}
+
+	if validationErrors != nil {
+		exception.HttpValidationError(
+			errors.New("Validation Error"),
+			w,
+			req,
+			"Invalid parameter",
+			validationErrors,
+		)
+		return true
+	}
+
+	return false
+}
@@ -10,13 +10,14 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mhvn092/movie-go/pkg/exception"
 )
 
-// validateInterface validates a struct or slice of structs and returns a list of validation errors.
-func validateInterface(s interface{}) []string {
-	var errors []string
+// validateInterface validates a struct or slice of structs and returns a list of field errors.
+func validateInterface(s interface{}) []exception.FieldError {
+	var errors []exception.FieldError
 	val := reflect.ValueOf(s)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
@@ -27,7 +28,7 @@ func validateInterface(s interface{}) []string {
 		for i := 0; i < val.Len(); i++ {
 			item := val.Index(i)
 			// Recursively validate each element in the slice
-			itemErrors := validateSingleStruct(item, fmt.Sprintf("[%d]", i))
+			itemErrors := validateSingleStruct(item, fmt.Sprintf("[%d].", i))
 			errors = append(errors, itemErrors...)
 		}
 		return errors
@@ -35,15 +36,16 @@ func validateInterface(s interface{}) []string {
 
 	// Handle structs
 	if val.Kind() != reflect.Struct {
-		return []string{"invalid interface passed as input"}
+		return []exception.FieldError{{Message: "invalid interface passed as input"}}
 	}
 
 	return validateSingleStruct(val, "")
 }
 
-// validateSingleStruct validates a single struct and prefixes field names with context (e.g., "Staffs[0].").
-func validateSingleStruct(val reflect.Value, prefix string) []string {
-	var errors []string
+// validateSingleStruct validates a single struct and prefixes field paths with context
+// (e.g., "movie_staffs[0].").
+func validateSingleStruct(val reflect.Value, prefix string) []exception.FieldError {
+	var errors []exception.FieldError
 	typ := val.Type()
 
 	// Loop through struct fields
@@ -51,14 +53,27 @@ func validateSingleStruct(val reflect.Value, prefix string) []string {
 		field := typ.Field(i)
 		value := val.Field(i)
 		tag := field.Tag.Get("validate")
-		fieldName := prefix + field.Name
+		fieldName := prefix + requestFieldName(field)
 
 		if tag == "" {
 			continue // Skip fields without validation tags
 		}
 
+		addError := func(message string) {
+			errors = append(errors, exception.FieldError{Field: fieldName, Message: message})
+		}
+
+		// Split validation rules
+		rules := strings.Split(tag, ",")
+		for i := range rules {
+			rules[i] = strings.TrimSpace(rules[i])
+		}
+
 		// Handle nested slices
 		if value.Kind() == reflect.Slice {
+			if containsString(rules, "required") && value.Len() == 0 {
+				addError("is required and cannot be empty")
+			}
 			for j := 0; j < value.Len(); j++ {
 				item := value.Index(j)
 				if item.Kind() == reflect.Struct {
@@ -73,63 +88,84 @@ func validateSingleStruct(val reflect.Value, prefix string) []string {
 			continue
 		}
 
-		// Split validation rules
-		rules := strings.Split(tag, ",")
+		// Optional fields are only validated when they were sent
+		if containsString(rules, "omitempty") && value.IsZero() {
+			continue
+		}
+
 		for _, rule := range rules {
-			rule = strings.TrimSpace(rule)
 
 			if rule == "required" && isEmpty(value) {
-				errors = append(errors, fieldName+" is required")
+				addError("is required")
 			}
 
 			if rule == "is_string" && value.Type().Kind() != reflect.String {
-				errors = append(errors, fieldName+" must be a string")
-			}
-
-			if rule == "required" && value.Kind() == reflect.Slice && value.Len() == 0 {
-				errors = append(errors, fieldName+" is required and cannot be empty")
+				addError("must be a string")
 			}
 
 			if rule == "is_int" && value.Type().Kind() != reflect.Int {
-				errors = append(errors, fieldName+" must be an int")
+				addError("must be an int")
 			}
 
 			if rule == "is_email" && !isValidEmail(value.String()) {
-				errors = append(errors, fieldName+" must be a valid email")
+				addError("must be a valid email")
 			}
 
 			if rule == "is_date_string" && !isValidDate(value.String()) {
-				errors = append(
-					errors,
-					fieldName+" must be a valid date string with the format of 2025-07-01",
-				)
+				addError("must be a valid date string with the format of 2025-07-01")
+			}
+
+			if rule == "is_datetime_string" && !isValidDateTime(value.String()) {
+				addError("must be a valid date or RFC 3339 date time, e.g. 2025-07-01T15:04:05Z")
 			}
 
 			if rule == "is_strong_password" && !isStrongPassword(value.String()) {
-				errors = append(errors, fieldName+" you should choose a strong password")
+				addError("you should choose a strong password")
 			}
 
 			if rule == "is_phone_number" && !isValidPhoneNumber(value.String()) {
-				errors = append(errors, fieldName+" phone number is not valid")
+				addError("phone number is not valid")
+			}
+
+			if strings.HasPrefix(rule, "min=") && value.Kind() == reflect.Int {
+				min := parseRuleInt(rule, "min=%d")
+				if value.Int() < int64(min) {
+					addError(fmt.Sprintf("must be at least %d", min))
+				}
+			}
+
+			if strings.HasPrefix(rule, "max=") && value.Kind() == reflect.Int {
+				max := parseRuleInt(rule, "max=%d")
+				if value.Int() > int64(max) {
+					addError(fmt.Sprintf("must be at most %d", max))
+				}
+			}
+
+			if strings.HasPrefix(rule, "one_of=") {
+				allowed := strings.Fields(strings.TrimPrefix(rule, "one_of="))
+				if !containsString(allowed, value.String()) {
+					addError("must be one of " + strings.Join(allowed, ", "))
+				}
 			}
 
 			if strings.HasPrefix(rule, "min_len=") {
 				minLen := parseMinLen(rule)
 				if len(value.String()) < minLen {
-					errors = append(
-						errors,
-						fieldName+fmt.Sprintf(" must be at least %d characters long", minLen),
-					)
+					addError(fmt.Sprintf("must be at least %d characters long", minLen))
+				}
+			}
+
+			if strings.HasPrefix(rule, "max_len=") {
+				maxLen := parseRuleInt(rule, "max_len=%d")
+				if utf8.RuneCountInString(value.String()) > maxLen {
+					addError(fmt.Sprintf("must be at most %d characters long", maxLen))
 				}
 			}
 
 			// Custom validation for ProductionYear
 			if rule == "is_valid_year" && value.Type().Kind() == reflect.Int {
 				if !isValidProductionYear(value.Int()) {
-					errors = append(
-						errors,
-						fieldName+" must be a valid production year between 1888 and 2030",
-					)
+					addError("must be a valid production year between 1888 and 2030")
 				}
 			}
 		}
@@ -138,6 +174,27 @@ func validateSingleStruct(val reflect.Value, prefix string) []string {
 	return errors
 }
 
+// requestFieldName returns the name a field has in the request body or query string,
+// falling back to the Go name.
+func requestFieldName(field reflect.StructField) string {
+	for _, tagName := range []string{"json", "query"} {
+		name := strings.Split(field.Tag.Get(tagName), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func containsString(list []string, item string) bool {
+	for _, candidate := range list {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
+
 // isValidProductionYear checks if the year is within a reasonable range.
 func isValidProductionYear(year int64) bool {
 	currentYear := time.Now().Year()
@@ -189,22 +246,55 @@ func parseMinLen(rule string) int {
 	return length
 }
 
+// Helper: Parse the number of a rule like max=20
+func parseRuleInt(rule string, format string) int {
+	var n int
+	fmt.Sscanf(rule, format, &n)
+	return n
+}
+
 func isValidDate(dateString string) bool {
 	_, err := time.Parse("2006-01-02", dateString)
 	return err == nil
 }
 
+func isValidDateTime(dateTimeString string) bool {
+	_, err := ParseDateTime(dateTimeString)
+	return err == nil
+}
+
+// ParseDateTime accepts either an RFC 3339 date time or a plain 2006-01-02 date.
+func ParseDateTime(dateTimeString string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, dateTimeString); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", dateTimeString)
+}
+
+// Validate checks a struct, or a slice of structs, against its validate tags outside of a
+// request, returning every invalid field.
+func Validate(payload interface{}) []exception.FieldError {
+	return validateInterface(payload)
+}
+
 func JsonBodyHasErrors(req *http.Request, w http.ResponseWriter, payload interface{}) bool {
 	// Read and check the request body
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		exception.HttpError(err, w, "Failed to read request body", http.StatusInternalServerError)
+		exception.HttpError(
+			err,
+			w,
+			req,
+			"Failed to read request body",
+			http.StatusInternalServerError,
+		)
 		return true
 	}
 	if len(body) == 0 {
 		exception.HttpError(
 			errors.New("Validation Error"),
 			w,
+			req,
 			"Empty request body",
 			http.StatusBadRequest,
 		)
@@ -213,19 +303,32 @@ func JsonBodyHasErrors(req *http.Request, w http.ResponseWriter, payload interfa
 
 	err = json.Unmarshal(body, payload)
 	if err != nil {
-		exception.HttpError(err, w, "Invalid JSON payload", http.StatusBadRequest)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			exception.HttpValidationError(
+				err,
+				w,
+				req,
+				"Invalid JSON payload",
+				[]exception.FieldError{
+					{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()},
+				},
+			)
+			return true
+		}
+		exception.HttpError(err, w, req, "Invalid JSON payload", http.StatusBadRequest)
 		return true
 	}
 
 	// Validate the payload
 	validationErrors := validateInterface(payload)
 	if validationErrors != nil {
-
-		exception.HttpError(
-			errors.New(strings.Join(validationErrors, " ,")),
+		exception.HttpValidationError(
+			errors.New("Validation Error"),
 			w,
+			req,
 			"Invalid Input sent",
-			http.StatusBadRequest,
+			validationErrors,
 		)
 		return true
 	}
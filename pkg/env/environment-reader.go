@@ -12,6 +12,34 @@ const (
 	PORT           = "PORT"
 	ENVIROMENT     = "ENVIROMENT"
 	JWT_SECRET_KEY = "JWT_SECRET_KEY"
+
+	CURSOR_SECRET_KEY = "CURSOR_SECRET_KEY"
+	ACCESS_TOKEN_TTL  = "ACCESS_TOKEN_TTL"
+	REFRESH_TOKEN_TTL = "REFRESH_TOKEN_TTL"
+	JWT_KEYS_DIR      = "JWT_KEYS_DIR"
+	JWT_ACTIVE_KEY_ID = "JWT_ACTIVE_KEY_ID"
+
+	APP_URL                    = "APP_URL"
+	MAILER                     = "MAILER"
+	MAIL_FROM                  = "MAIL_FROM"
+	MAIL_LOG_FILE              = "MAIL_LOG_FILE"
+	SMTP_HOST                  = "SMTP_HOST"
+	SMTP_PORT                  = "SMTP_PORT"
+	SMTP_USERNAME              = "SMTP_USERNAME"
+	SMTP_PASSWORD              = "SMTP_PASSWORD"
+	REQUIRE_EMAIL_VERIFICATION = "REQUIRE_EMAIL_VERIFICATION"
+	PASSWORD_RESET_TTL         = "PASSWORD_RESET_TTL"
+	EMAIL_VERIFICATION_TTL     = "EMAIL_VERIFICATION_TTL"
+
+	TOTP_ISSUER          = "TOTP_ISSUER"
+	REQUIRE_ADMIN_2FA    = "REQUIRE_ADMIN_2FA"
+	TWO_FACTOR_LOGIN_TTL = "TWO_FACTOR_LOGIN_TTL"
+
+	TRUSTED_PROXIES   = "TRUSTED_PROXIES"
+	LOGIN_IP_ATTEMPTS = "LOGIN_IP_ATTEMPTS"
+
+	MIGRATION_LOCK_TIMEOUT = "MIGRATION_LOCK_TIMEOUT"
+	AUTO_MIGRATE           = "AUTO_MIGRATE"
 )
 
 var envValues = make(map[string]string)
@@ -0,0 +1,158 @@
+package exception
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds, usable as targets for errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("already exists")
+	ErrValidation   = errors.New("is not valid")
+	ErrForbidden    = errors.New("is forbidden")
+	ErrUnauthorized = errors.New("is unauthorized")
+	ErrTooMany      = errors.New("has too many requests")
+)
+
+// DomainError is returned by services and repositories to describe a failure
+// in terms of the resource it happened on, so handlers don't have to guess.
+type DomainError struct {
+	Kind     error
+	Resource string
+	Ids      []int
+	Message  string
+	Fields   []FieldError
+	Err      error
+	// RetryAfter tells the client when to try again, sent as the Retry-After header
+	RetryAfter time.Duration
+}
+
+func (e *DomainError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	var b strings.Builder
+	b.WriteString(e.Resource)
+	switch len(e.Ids) {
+	case 0:
+	case 1:
+		b.WriteString(" " + strconv.Itoa(e.Ids[0]))
+	default:
+		ids := make([]string, len(e.Ids))
+		for i, id := range e.Ids {
+			ids[i] = strconv.Itoa(id)
+		}
+		b.WriteString(" with ids " + strings.Join(ids, ", "))
+	}
+	b.WriteString(" " + e.Kind.Error())
+	return b.String()
+}
+
+// Is makes errors.Is(err, ErrNotFound) and friends work on a DomainError.
+func (e *DomainError) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+// WithCause attaches the underlying error, which is only ever logged.
+func (e *DomainError) WithCause(err error) *DomainError {
+	e.Err = err
+	return e
+}
+
+func NotFound(resource string, ids ...int) *DomainError {
+	return &DomainError{Kind: ErrNotFound, Resource: resource, Ids: ids}
+}
+
+func Conflict(resource string, ids ...int) *DomainError {
+	return &DomainError{Kind: ErrConflict, Resource: resource, Ids: ids}
+}
+
+// StillReferenced reports a delete refused because other rows still point at the resource.
+func StillReferenced(resource string, id int, by string) *DomainError {
+	return &DomainError{
+		Kind:     ErrConflict,
+		Resource: resource,
+		Ids:      []int{id},
+		Message:  resource + " " + strconv.Itoa(id) + " is still used by " + by,
+	}
+}
+
+func Validation(resource string, message string) *DomainError {
+	return &DomainError{Kind: ErrValidation, Resource: resource, Message: message}
+}
+
+// InvalidFields reports a validation failure on one or more request fields.
+func InvalidFields(resource string, message string, fields []FieldError) *DomainError {
+	return &DomainError{Kind: ErrValidation, Resource: resource, Message: message, Fields: fields}
+}
+
+func Forbidden(resource string, message string) *DomainError {
+	return &DomainError{Kind: ErrForbidden, Resource: resource, Message: message}
+}
+
+func Unauthorized(resource string, message string) *DomainError {
+	return &DomainError{Kind: ErrUnauthorized, Resource: resource, Message: message}
+}
+
+// TooManyRequests refuses a request for now, telling the client to wait for retryAfter.
+func TooManyRequests(resource string, message string, retryAfter time.Duration) *DomainError {
+	return &DomainError{Kind: ErrTooMany, Resource: resource, Message: message, RetryAfter: retryAfter}
+}
+
+func statusForKind(kind error) int {
+	switch kind {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict:
+		return http.StatusConflict
+	case ErrValidation:
+		return http.StatusBadRequest
+	case ErrForbidden:
+		return http.StatusForbidden
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrTooMany:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// HttpDomainError maps any error coming out of a service to an http response.
+// Anything that is not a DomainError is treated as an internal error.
+func HttpDomainError(e error, w http.ResponseWriter, r *http.Request) {
+	if e == nil {
+		return
+	}
+
+	var domainErr *DomainError
+	if !errors.As(e, &domainErr) {
+		logRealError(e, "unexpected error")
+		DefaultInternalHttpError(w, r)
+		return
+	}
+
+	cause := e
+	if domainErr.Err != nil {
+		cause = domainErr.Err
+	}
+	if domainErr.RetryAfter > 0 {
+		seconds := int(math.Ceil(domainErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	if len(domainErr.Fields) > 0 {
+		HttpValidationError(cause, w, r, domainErr.Error(), domainErr.Fields)
+		return
+	}
+	HttpError(cause, w, r, domainErr.Error(), statusForKind(domainErr.Kind))
+}
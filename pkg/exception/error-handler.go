@@ -19,21 +19,42 @@ func ErrorExit(e error, message string) {
 	}
 }
 
-func HttpError(e error, w http.ResponseWriter, message string, code int) {
+func HttpError(e error, w http.ResponseWriter, r *http.Request, message string, code int) {
 	if e != nil {
-		if env.GetEnv(env.ENVIROMENT) == "development" {
-			err := fmt.Sprintf("%s: the real error(%s)", message, e.Error())
-			fmt.Println(err)
-		}
-		http.Error(w, message, code)
+		logRealError(e, message)
+		writeProblem(w, newProblem(r, message, code))
 	}
 }
 
-func DefaultInternalHttpError(w http.ResponseWriter) {
+// HttpValidationError responds with 400 and lists every invalid field.
+func HttpValidationError(
+	e error,
+	w http.ResponseWriter,
+	r *http.Request,
+	message string,
+	fields []FieldError,
+) {
+	if e != nil {
+		logRealError(e, message)
+		p := newProblem(r, message, http.StatusBadRequest)
+		p.Errors = fields
+		writeProblem(w, p)
+	}
+}
+
+func DefaultInternalHttpError(w http.ResponseWriter, r *http.Request) {
 	HttpError(
 		errors.New("Some Unexpected Error Happened, Please Try again later"),
 		w,
+		r,
 		"Some Unexpected Error Happened, Please Try again later",
 		http.StatusInternalServerError,
 	)
 }
+
+func logRealError(e error, message string) {
+	if env.GetEnv(env.ENVIROMENT) == "development" {
+		err := fmt.Sprintf("%s: the real error(%s)", message, e.Error())
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,54 @@
+package exception
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RequestIdHeader carries the id the request-id middleware assigned to a request.
+const RequestIdHeader = "X-Request-Id"
+
+const problemContentType = "application/problem+json"
+
+// FieldError points at a single invalid field using its JSON path, e.g. movie_staffs[2].staff_id.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestId string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func newProblem(r *http.Request, detail string, code int) Problem {
+	title := http.StatusText(code)
+	return Problem{
+		Type:      "/problems/" + strings.ReplaceAll(strings.ToLower(title), " ", "-"),
+		Title:     title,
+		Status:    code,
+		Detail:    detail,
+		Instance:  strings.SplitN(r.RequestURI, "?", 2)[0],
+		RequestId: r.Header.Get(RequestIdHeader),
+	}
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"io"
+	"sync"
+)
+
+// LogMailer writes the messages to out instead of sending them, so the links in them can
+// be followed in development and read back in tests.
+type LogMailer struct {
+	mu   sync.Mutex
+	out  io.Writer
+	from string
+}
+
+func NewLogMailer(out io.Writer, from string) *LogMailer {
+	return &LogMailer{out: out, from: from}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.out.Write(format(m.from, msg)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.out, "\r\n")
+	return err
+}
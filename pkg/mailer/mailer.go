@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+)
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewFromEnv picks the mailer by MAILER: "smtp" sends through SMTP_HOST, anything else
+// writes the emails to MAIL_LOG_FILE, or to stdout when it's empty, for development.
+func NewFromEnv() (Mailer, error) {
+	from := env.GetEnv(env.MAIL_FROM)
+
+	if env.GetEnv(env.MAILER) == "smtp" {
+		return NewSMTPMailer(SMTPConfig{
+			Host:     env.GetEnv(env.SMTP_HOST),
+			Port:     env.GetEnv(env.SMTP_PORT),
+			Username: env.GetEnv(env.SMTP_USERNAME),
+			Password: env.GetEnv(env.SMTP_PASSWORD),
+			From:     from,
+		})
+	}
+
+	path := env.GetEnv(env.MAIL_LOG_FILE)
+	if path == "" {
+		return NewLogMailer(os.Stdout, from), nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the mail log file: %w", err)
+	}
+	return NewLogMailer(file, from), nil
+}
+
+// format renders the message with its headers, dropping line breaks from the header
+// values so a crafted subject or address can't add headers of its own.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer sends every message over a new SMTP connection, upgraded with STARTTLS
+// when the server offers it.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, errors.New("the smtp mailer needs a host and a from address")
+	}
+
+	port := config.Port
+	if port == "" {
+		port = "587"
+	}
+
+	m := &SMTPMailer{addr: net.JoinHostPort(config.Host, port), from: config.From}
+	if config.Username != "" {
+		m.auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, format(m.from, msg))
+}
@@ -0,0 +1,209 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Drift is an applied migration whose file no longer matches what was applied.
+type Drift struct {
+	Name    string
+	Version int
+	// Missing tells the file was deleted rather than changed
+	Missing bool
+	// Unrecorded tells no checksum was kept when it was applied, so it can't be checked
+	Unrecorded bool
+}
+
+func (d Drift) String() string {
+	if d.Missing {
+		return fmt.Sprintf("%s: applied but the file is missing", d.Name)
+	}
+	if d.Unrecorded {
+		return fmt.Sprintf("%s: no checksum recorded", d.Name)
+	}
+	return fmt.Sprintf("%s: the file was changed after it was applied", d.Name)
+}
+
+// DriftError stops a run while applied migrations drifted.
+type DriftError struct {
+	Drifts []Drift
+}
+
+func (e *DriftError) Error() string {
+	names := make([]string, 0, len(e.Drifts))
+	for _, drift := range e.Drifts {
+		names = append(names, drift.String())
+	}
+	return fmt.Sprintf(
+		"%d applied migrations do not match their files (%s), restore the files, repair them once the database is known to match or forget the ones removed on purpose",
+		len(e.Drifts),
+		strings.Join(names, "; "),
+	)
+}
+
+// Verify compares every applied migration with its file without changing the database. Rows
+// from before checksums were kept are reported as unrecorded, repair or the next run records
+// them with the file as it is then.
+func (m *Migrator) Verify() (drifts []Drift, err error) {
+	err = m.withLock(func() error {
+		tableExists, hasChecksum, err := m.migrationTableState()
+		if err != nil || !tableExists {
+			drifts = []Drift{}
+			return err
+		}
+
+		query := getAllMigrationQuery()
+		if !hasChecksum {
+			query = getAllMigrationWithoutChecksumQuery()
+		}
+		appliedMigrations, err := m.queryAppliedMigrations(query)
+		if err != nil {
+			return err
+		}
+		drifts, err = m.findDrift(appliedMigrations, false)
+		return err
+	})
+	return
+}
+
+// findDrift compares the applied migrations with their files. With baseline, rows without a
+// checksum are recorded with the file as it is now, since what was applied back then is
+// unknown, rather than reported.
+func (m *Migrator) findDrift(appliedMigrations map[string]appliedMigration, baseline bool) ([]Drift, error) {
+	applied, err := sortApplied(appliedMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := []Drift{}
+	for _, migration := range applied {
+		if !m.hasMigrationFile(migration.Name) {
+			drifts = append(drifts, Drift{Name: migration.Name, Version: migration.Version, Missing: true})
+			continue
+		}
+
+		checksum, err := m.fileChecksum(migration.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		recorded := appliedMigrations[migration.Name].Checksum
+		if recorded == nil && !baseline {
+			drifts = append(drifts, Drift{Name: migration.Name, Version: migration.Version, Unrecorded: true})
+			continue
+		}
+		if recorded == nil {
+			if err := m.updateChecksum(migration.Name, checksum); err != nil {
+				return nil, err
+			}
+			m.logger.Printf("Recorded the checksum of migration: %s", migration.Name)
+			continue
+		}
+		if *recorded != checksum {
+			drifts = append(drifts, Drift{Name: migration.Name, Version: migration.Version})
+		}
+	}
+	return drifts, nil
+}
+
+// PrintDrift writes a report of the drifted migrations.
+func PrintDrift(out io.Writer, drifts []Drift) {
+	if len(drifts) == 0 {
+		fmt.Fprintln(out, "every applied migration matches its file")
+		return
+	}
+
+	fmt.Fprintf(out, "%d applied migrations can't be verified against their files:\n", len(drifts))
+	for _, drift := range drifts {
+		fmt.Fprintf(out, "  %s\n", drift)
+	}
+	fmt.Fprintln(out, "Restore the files, or run repair once the database is known to match them; a migration")
+	fmt.Fprintln(out, "whose file was removed on purpose is forgotten with repair --version <version> --forget-missing.")
+	fmt.Fprintln(out, "Checksums that were never recorded are recorded by repair or the next run.")
+}
+
+// RepairOptions picks the applied migrations Repair records.
+type RepairOptions struct {
+	// Version limits the repair to one migration when it's not 0
+	Version int
+	// ForgetMissing deletes the row of the migration Version when its file is gone, for a
+	// migration that was removed on purpose. Its changes stay in the database.
+	ForgetMissing bool
+}
+
+// Repair records the files of the applied migrations as they are now, after the database was
+// brought in line with them by hand. Applied migrations without a file are left alone unless
+// forgotten one at a time with ForgetMissing. It returns the names of the migrations whose
+// checksum changed or that were forgotten.
+func (m *Migrator) Repair(opts RepairOptions) (repaired []string, err error) {
+	if opts.ForgetMissing && opts.Version == 0 {
+		return nil, errors.New("forgetting a migration without a file needs its version")
+	}
+
+	err = m.withLock(func() error {
+		if err := m.ensureMigrationTable(); err != nil {
+			return err
+		}
+		repaired, err = m.repair(opts)
+		return err
+	})
+	return
+}
+
+func (m *Migrator) repair(opts RepairOptions) ([]string, error) {
+	appliedMigrations, err := m.readAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.readAppliedMigrationsSorted()
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := []string{}
+	found := false
+	for _, migration := range applied {
+		if opts.Version != 0 && migration.Version != opts.Version {
+			continue
+		}
+		found = true
+
+		if !m.hasMigrationFile(migration.Name) {
+			if !opts.ForgetMissing {
+				m.logger.Printf("Skipped migration without a file: %s", migration.Name)
+				continue
+			}
+			_, err := m.db.Exec(context.Background(), getUpdatingMigrationTableQuery(true), migration.Name)
+			if err != nil {
+				return nil, err
+			}
+			repaired = append(repaired, migration.Name)
+			continue
+		}
+		if opts.ForgetMissing {
+			return nil, fmt.Errorf("migration %s still has its file, only migrations without one can be forgotten", migration.Name)
+		}
+
+		checksum, err := m.fileChecksum(migration.Name)
+		if err != nil {
+			return nil, err
+		}
+		recorded := appliedMigrations[migration.Name].Checksum
+		if recorded != nil && *recorded == checksum {
+			continue
+		}
+		if err := m.updateChecksum(migration.Name, checksum); err != nil {
+			return nil, err
+		}
+		repaired = append(repaired, migration.Name)
+	}
+
+	if opts.Version != 0 && !found {
+		return nil, fmt.Errorf("migration with version %d is not applied", opts.Version)
+	}
+	return repaired, nil
+}
@@ -2,142 +2,84 @@ package migration
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/mhvn092/movie-go/pkg/exception"
-	"log"
-	"strings"
+	"sort"
+	"time"
 )
 
-func ensureMigrationTable(conn *pgxpool.Pool) {
-	row, err := conn.Query(context.Background(), checkExistenceOfMigrationTableQuery())
-	defer row.Close()
-	if err != nil {
-		exception.ErrorExit(err, "could not query the migrations table")
-	}
-	fmt.Println("ensured migrations table exist")
+// appliedMigration is a row of the migrations table.
+type appliedMigration struct {
+	AppliedAt time.Time
+	// Checksum is of the file as it was applied, nil for rows from before checksums were kept
+	Checksum *string
 }
 
-func readAllMigrationsFromDb(conn *pgxpool.Pool) map[string]bool {
-	var appliedMigrations = make(map[string]bool)
-	rows, err := conn.Query(context.Background(), getAllMigrationQuery())
-	if err != nil {
-		exception.ErrorExit(err, "could not query the migrations table")
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			exception.ErrorExit(err, "could not read the row from the migrations table")
+func (m *Migrator) ensureMigrationTable() error {
+	for _, query := range []string{checkExistenceOfMigrationTableQuery(), addChecksumColumnQuery()} {
+		if _, err := m.db.Exec(context.Background(), query); err != nil {
+			return err
 		}
-		appliedMigrations[name] = true
 	}
-	if err := rows.Err(); err != nil {
-		exception.ErrorExit(err, "could not read the rows from the migrations table")
-	}
-	return appliedMigrations
+	return nil
 }
 
-func readTheLastMigrationsFromDb(conn *pgxpool.Pool) string {
-	rows, err := conn.Query(context.Background(), getLastMigrationQuery())
-	if err != nil {
-		exception.ErrorExit(err, "could not query the migrations table")
-	}
-	defer rows.Close()
-
-	var name string
-
-	if rows.Next() {
-		if err := rows.Scan(&name); err != nil {
-			exception.ErrorExit(err, "could not read the row from the migrations table")
-		}
-	} else {
-		if err := rows.Err(); err != nil {
-			exception.ErrorExit(err, "error occurred during row iteration")
-		}
-		exception.ErrorExit(errors.New("no rows found"), "could not find any migrations")
-	}
-	return name
+// migrationTableState tells whether the migrations table and its checksum column exist,
+// without creating them like ensureMigrationTable.
+func (m *Migrator) migrationTableState() (tableExists bool, hasChecksum bool, err error) {
+	err = m.db.QueryRow(context.Background(), migrationTableStateQuery()).Scan(&tableExists, &hasChecksum)
+	return
 }
 
-func revertTheLastCommitedMigration(conn *pgxpool.Pool) {
-	lastMigrationName := readTheLastMigrationsFromDb(conn)
-	applyMigration(conn, lastMigrationName+".sql", true)
+// readAppliedMigrations returns the applied migrations by name.
+func (m *Migrator) readAppliedMigrations() (map[string]appliedMigration, error) {
+	return m.queryAppliedMigrations(getAllMigrationQuery())
 }
 
-func readMigrationsFromDirAndApply(conn *pgxpool.Pool, appliedMigrations map[string]bool) {
-	files := readMigrationsFromDirSorted()
-	if len(files) == 0 {
-		fmt.Println("no migration to run")
-		return
+// queryAppliedMigrations reads the rows of a query selecting name, applied_at and checksum.
+func (m *Migrator) queryAppliedMigrations(query string) (map[string]appliedMigration, error) {
+	rows, err := m.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
 	}
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".sql") {
-			var nameWithoutExtension = strings.TrimSuffix(file.Name(), ".sql")
-			if _, applied := appliedMigrations[nameWithoutExtension]; !applied {
-				applyMigration(conn, file.Name(), false)
-			}
+	defer rows.Close()
+
+	appliedMigrations := make(map[string]appliedMigration)
+	for rows.Next() {
+		var name string
+		var applied appliedMigration
+		if err := rows.Scan(&name, &applied.AppliedAt, &applied.Checksum); err != nil {
+			return nil, err
 		}
+		appliedMigrations[name] = applied
 	}
+	return appliedMigrations, rows.Err()
 }
-func applyMigration(conn *pgxpool.Pool, filename string, revert bool) {
-	file := readMigrationFile(filename, revert)
-	defer file.Close()
-	// Split the SQL statements by `;` and execute them within a transaction
-	sqlStatements := parseSQLStatements(file)
 
-	runMigrationStatementsInTransaction(sqlStatements, conn, filename, revert)
-}
-
-func runMigrationStatementsInTransaction(sqlStatements map[int]string, conn *pgxpool.Pool, filename string, revert bool) {
-	ctx := context.Background()
-	tx, err := conn.Begin(ctx)
+// readAppliedMigrationsSorted lists the applied migrations by their version, latest last.
+func (m *Migrator) readAppliedMigrationsSorted() ([]migrationFile, error) {
+	appliedMigrations, err := m.readAppliedMigrations()
 	if err != nil {
-		log.Fatalf("Failed to begin transaction for migration %s: %v\n", filename, err)
+		return nil, err
 	}
+	return sortApplied(appliedMigrations)
+}
 
-	defer func() {
-		if err != nil {
-			e := tx.Rollback(ctx)
-			if e != nil {
-				exception.ErrorExit(err, "could not rollback transaction")
-			}
-			exception.ErrorExit(err, fmt.Sprintf("Transaction rolled back for migration %s", filename))
-		} else {
-			err = tx.Commit(ctx)
-			if err != nil {
-				exception.ErrorExit(err, fmt.Sprintf("Failed to commit transaction for migration %s", filename))
-			}
-		}
-	}()
-
-	for _, statement := range sqlStatements {
-		_, err = tx.Exec(ctx, statement)
+func sortApplied(appliedMigrations map[string]appliedMigration) ([]migrationFile, error) {
+	applied := []migrationFile{}
+	for name := range appliedMigrations {
+		version, err := migrationVersion(name)
 		if err != nil {
-			return // This will cause the deferred function to roll back the transaction
+			return nil, err
 		}
+		applied = append(applied, migrationFile{Name: name, Version: version})
 	}
 
-	var nameWithoutExtension = strings.TrimSuffix(filename, ".sql")
-	// Log the applied migration
-	migrationTableStatement := getUpdatingMigrationTableQuery(revert)
-
-	_, err = tx.Exec(ctx, migrationTableStatement, nameWithoutExtension)
-	if err != nil {
-		return // This will cause the deferred function to roll back the transaction
-	}
-
-	fmt.Printf("Applied migration: %s\n", filename)
-}
-
-func RunMigrations(conn *pgxpool.Pool) {
-	ensureMigrationTable(conn)
-	appliedMigrations := readAllMigrationsFromDb(conn)
-	readMigrationsFromDirAndApply(conn, appliedMigrations)
+	sort.Slice(applied, func(i, j int) bool {
+		return applied[i].Version < applied[j].Version
+	})
+	return applied, nil
 }
 
-func RevertTheLastMigration(conn *pgxpool.Pool) {
-	ensureMigrationTable(conn)
-	revertTheLastCommitedMigration(conn)
+func (m *Migrator) updateChecksum(name string, checksum string) error {
+	_, err := m.db.Exec(context.Background(), updateChecksumQuery(), name, checksum)
+	return err
 }
@@ -1,72 +1,128 @@
 package migration
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"github.com/mhvn092/movie-go/pkg/exception"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-func readMigrationsFromDirSorted() []os.DirEntry {
-	pwd, _ := os.Getwd()
-	files, err := os.ReadDir(pwd + "/migrations/up")
+// migrationFile is a migration in the up directory, named <version>_<name>.sql.
+type migrationFile struct {
+	Name    string
+	Version int
+}
+
+// migrationVersion reads the version a migration name starts with.
+func migrationVersion(name string) (int, error) {
+	prefix, _, found := strings.Cut(name, "_")
+	version, err := strconv.Atoi(prefix)
+	if !found || err != nil || version <= 0 {
+		return 0, fmt.Errorf("migration %q does not start with a version like 12_", name)
+	}
+	return version, nil
+}
+
+// readMigrationsSorted lists the migrations by their version, so 10_ runs after 9_.
+func (m *Migrator) readMigrationsSorted() ([]migrationFile, error) {
+	return readMigrationsSorted(m.fsys)
+}
+
+func readMigrationsSorted(fsys fs.FS) ([]migrationFile, error) {
+	entries, err := fs.ReadDir(fsys, "up")
 	if err != nil {
-		exception.ErrorExit(err, "could not read the migrations directory")
+		return nil, fmt.Errorf("could not read the migrations directory: %w", err)
+	}
+
+	files := []migrationFile{}
+	versions := map[int]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		version, err := migrationVersion(name)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := versions[version]; exists {
+			return nil, fmt.Errorf("%s and %s have the same version", other, name)
+		}
+		versions[version] = name
+		files = append(files, migrationFile{Name: name, Version: version})
 	}
 
-	// Sort the migration files by name
 	sort.Slice(files, func(i, j int) bool {
-		return files[i].Name() < files[j].Name()
+		return files[i].Version < files[j].Version
 	})
-	return files
+	return files, nil
 }
 
-func getMigrationFilePath(filename string, revert bool) string {
-	pwd, _ := os.Getwd()
+func migrationFilePath(name string, revert bool) string {
 	folder := "up"
 	if revert {
 		folder = "down"
 	}
-	return filepath.Join(pwd, "migrations", folder, filename)
+	return path.Join(folder, name+".sql")
 }
 
-func readMigrationFile(filename string, revert bool) *os.File {
-	migrationFilePath := getMigrationFilePath(filename, revert)
-
-	file, err := os.Open(migrationFilePath)
+func (m *Migrator) readMigrationFile(name string, revert bool) ([]byte, error) {
+	body, err := fs.ReadFile(m.fsys, migrationFilePath(name, revert))
 	if err != nil {
-		exception.ErrorExit(err, "could not open the migration file")
+		return nil, fmt.Errorf("could not read the migration file: %w", err)
 	}
-	return file
+	return body, nil
+}
+
+func (m *Migrator) hasMigrationFile(name string) bool {
+	_, err := fs.Stat(m.fsys, migrationFilePath(name, false))
+	return err == nil
 }
 
-func getTheMigrationNameIndex() int {
-	files := readMigrationsFromDirSorted()
-	return len(files)
+// fileChecksum is the sha256 of an up migration. Line endings are normalized first, so a
+// checkout with CRLF endings matches one with LF endings.
+func (m *Migrator) fileChecksum(name string) (string, error) {
+	body, err := m.readMigrationFile(name, false)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")))
+	return hex.EncodeToString(sum[:]), nil
 }
 
-func CreateMigrationFile(name string) {
-	index := getTheMigrationNameIndex()
-	trimmedName := strings.TrimSpace(name)
-	spacedArray := strings.Split(trimmedName, " ")
-	if len(spacedArray) > 1 {
-		trimmedName = strings.Join(spacedArray, "_")
+// CreateMigrationFile adds an empty up and down migration to dir, numbered one past the
+// highest version so a gap left by a deleted migration is never reused. It returns the
+// name of the files.
+func CreateMigrationFile(dir string, name string) (string, error) {
+	trimmedName := strings.Join(strings.Fields(name), "_")
+	if trimmedName == "" {
+		return "", errors.New("no name provided")
 	}
-	finalName := strconv.Itoa(index+1) + "_" + trimmedName + ".sql"
-	upPath := getMigrationFilePath(finalName, false)
-	downPath := getMigrationFilePath(finalName, true)
 
-	_, err := os.Create(upPath)
+	files, err := readMigrationsSorted(os.DirFS(dir))
 	if err != nil {
-		exception.ErrorExit(err, "could not create up migration file")
+		return "", err
+	}
+	version := 1
+	if len(files) > 0 {
+		version = files[len(files)-1].Version + 1
 	}
 
-	_, err = os.Create(downPath)
-	if err != nil {
-		exception.ErrorExit(err, "could not create down migration file")
+	finalName := strconv.Itoa(version) + "_" + trimmedName + ".sql"
+	for _, folder := range []string{"up", "down"} {
+		file, err := os.Create(filepath.Join(dir, folder, finalName))
+		if err != nil {
+			return "", fmt.Errorf("could not create the %s migration file: %w", folder, err)
+		}
+		file.Close()
 	}
-	fmt.Println("migration file with the name " + finalName + " is created")
+	return finalName, nil
 }
@@ -0,0 +1,82 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mhvn092/movie-go/pkg/env"
+)
+
+// migrationLockKey names the advisory lock every migration run holds. It's an arbitrary
+// number no other lock of the application uses.
+const migrationLockKey int64 = 7_248_169_530_117
+
+const (
+	defaultLockTimeout = 5 * time.Minute
+	lockRetryInterval  = time.Second
+)
+
+// lockTimeout is MIGRATION_LOCK_TIMEOUT, or 5 minutes.
+func lockTimeout() time.Duration {
+	timeout, err := time.ParseDuration(env.GetEnv(env.MIGRATION_LOCK_TIMEOUT))
+	if err != nil || timeout < 0 {
+		return defaultLockTimeout
+	}
+	return timeout
+}
+
+// withLock runs fn holding the migration lock, so replicas starting at the same time apply
+// every migration once, one after the other. The lock belongs to a connection of its own
+// and goes away with it, even when the process dies halfway.
+func (m *Migrator) withLock(fn func() error) (err error) {
+	ctx := context.Background()
+	lockConn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get a connection for the migration lock: %w", err)
+	}
+	defer lockConn.Release()
+
+	if err := m.waitForLock(ctx, lockConn); err != nil {
+		return err
+	}
+	defer func() {
+		_, unlockErr := lockConn.Exec(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		if unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("could not release the migration lock: %w", unlockErr))
+		}
+	}()
+
+	return fn()
+}
+
+func (m *Migrator) waitForLock(ctx context.Context, lockConn *pgxpool.Conn) error {
+	deadline := time.Now().Add(m.LockTimeout)
+	waiting := false
+
+	for {
+		var locked bool
+		err := lockConn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&locked)
+		if err != nil {
+			return fmt.Errorf("could not take the migration lock: %w", err)
+		}
+		if locked {
+			if waiting {
+				m.logger.Printf("took the migration lock")
+			}
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("another migration run held the lock for longer than %s", m.LockTimeout)
+		}
+		if !waiting {
+			m.logger.Printf("another migration run holds the lock, waiting up to %s for it to finish", m.LockTimeout)
+			waiting = true
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
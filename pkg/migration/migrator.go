@@ -0,0 +1,264 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Logger is what a Migrator reports its progress to; *log.Logger is one.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Migrator applies and reverts the migrations of an fs.FS holding an up and a down
+// directory of <version>_<name>.sql files, like the one embedded by the migrations package.
+type Migrator struct {
+	fsys   fs.FS
+	db     *pgxpool.Pool
+	logger Logger
+	// LockTimeout is how long a run waits for another one to finish, 0 gives up right away
+	LockTimeout time.Duration
+}
+
+// UpOptions narrows down which pending migrations Up applies.
+type UpOptions struct {
+	// To is the last version to apply, every pending one when 0
+	To int
+	// DryRun logs the statements instead of running them
+	DryRun bool
+}
+
+// DownOptions picks the applied migrations Down reverts, the last one by default.
+type DownOptions struct {
+	// Steps is how many of the last applied migrations to revert
+	Steps int
+	// To, when set, reverts every migration after this version instead, all of them for 0
+	To *int
+	// DryRun logs the statements instead of running them
+	DryRun bool
+}
+
+func NewMigrator(fsys fs.FS, db *pgxpool.Pool, logger Logger) *Migrator {
+	return &Migrator{fsys: fsys, db: db, logger: logger, LockTimeout: lockTimeout()}
+}
+
+// Up applies the pending migrations in the order of their versions. It refuses to when an
+// applied migration was changed since.
+func (m *Migrator) Up(opts UpOptions) error {
+	return m.withLock(func() error {
+		if err := m.prepare(); err != nil {
+			return err
+		}
+
+		files, err := m.readMigrationsSorted()
+		if err != nil {
+			return err
+		}
+		if opts.To != 0 && !hasVersion(files, opts.To) {
+			return fmt.Errorf("there is no migration with version %d", opts.To)
+		}
+
+		applied, err := m.readAppliedMigrations()
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		for _, file := range files {
+			if opts.To != 0 && file.Version > opts.To {
+				break
+			}
+			if _, ok := applied[file.Name]; ok {
+				continue
+			}
+			if err := m.applyMigration(file.Name, false, opts.DryRun); err != nil {
+				return err
+			}
+			pending++
+		}
+
+		if pending == 0 {
+			m.logger.Printf("no migration to run")
+		}
+		return nil
+	})
+}
+
+// Down reverts applied migrations, latest version first. It refuses to when an applied
+// migration was changed since.
+func (m *Migrator) Down(opts DownOptions) error {
+	return m.withLock(func() error {
+		if err := m.prepare(); err != nil {
+			return err
+		}
+
+		applied, err := m.readAppliedMigrationsSorted()
+		if err != nil {
+			return err
+		}
+
+		var toRevert []migrationFile
+		if opts.To != nil {
+			if *opts.To != 0 && !hasVersion(applied, *opts.To) {
+				return fmt.Errorf("migration with version %d is not applied", *opts.To)
+			}
+			for _, migration := range applied {
+				if migration.Version > *opts.To {
+					toRevert = append(toRevert, migration)
+				}
+			}
+		} else {
+			steps := max(opts.Steps, 1)
+			if steps > len(applied) {
+				return fmt.Errorf("asked to revert %d migrations but %d are applied", steps, len(applied))
+			}
+			toRevert = applied[len(applied)-steps:]
+		}
+
+		if len(toRevert) == 0 {
+			m.logger.Printf("no migration to revert")
+			return nil
+		}
+		for i := len(toRevert) - 1; i >= 0; i-- {
+			if err := m.applyMigration(toRevert[i].Name, true, opts.DryRun); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Redo reverts the latest applied migration and applies it again.
+func (m *Migrator) Redo(dryRun bool) error {
+	return m.withLock(func() error {
+		if err := m.prepare(); err != nil {
+			return err
+		}
+
+		applied, err := m.readAppliedMigrationsSorted()
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return errors.New("there is no applied migration to redo")
+		}
+
+		last := applied[len(applied)-1].Name
+		if err := m.applyMigration(last, true, dryRun); err != nil {
+			return err
+		}
+		return m.applyMigration(last, false, dryRun)
+	})
+}
+
+// prepare makes sure the migrations table exists and that no applied migration drifted,
+// since the database may not be what the later migrations expect then.
+func (m *Migrator) prepare() error {
+	if err := m.ensureMigrationTable(); err != nil {
+		return err
+	}
+
+	appliedMigrations, err := m.readAppliedMigrations()
+	if err != nil {
+		return err
+	}
+	drifts, err := m.findDrift(appliedMigrations, true)
+	if err != nil {
+		return err
+	}
+	if len(drifts) > 0 {
+		return &DriftError{Drifts: drifts}
+	}
+	return nil
+}
+
+// applyMigration runs the up or down file of a migration in a transaction, along with
+// recording it in the migrations table.
+func (m *Migrator) applyMigration(name string, revert bool, dryRun bool) error {
+	body, err := m.readMigrationFile(name, revert)
+	if err != nil {
+		return err
+	}
+
+	sqlStatements, err := parseSQLStatements(body)
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", name, err)
+	}
+
+	if dryRun {
+		m.logStatements(sqlStatements, name, revert)
+		return nil
+	}
+
+	// the checksum is of the up file, which is what drift is checked against
+	args := []interface{}{name}
+	if !revert {
+		checksum, err := m.fileChecksum(name)
+		if err != nil {
+			return err
+		}
+		args = append(args, checksum)
+	}
+
+	err = m.inTransaction(func(tx pgx.Tx) error {
+		for _, statement := range sqlStatements {
+			if _, err := tx.Exec(context.Background(), statement.SQL); err != nil {
+				return statementError(statement, err)
+			}
+		}
+		_, err := tx.Exec(context.Background(), getUpdatingMigrationTableQuery(revert), args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %s was rolled back: %w", name, err)
+	}
+
+	if revert {
+		m.logger.Printf("Reverted migration: %s", name)
+	} else {
+		m.logger.Printf("Applied migration: %s", name)
+	}
+	return nil
+}
+
+func (m *Migrator) logStatements(sqlStatements []Statement, name string, revert bool) {
+	direction := "apply"
+	if revert {
+		direction = "revert"
+	}
+	m.logger.Printf("-- would %s migration: %s", direction, name)
+	for _, statement := range sqlStatements {
+		m.logger.Printf("-- line %d\n%s;", statement.Line, statement.SQL)
+	}
+}
+
+func (m *Migrator) inTransaction(fn func(tx pgx.Tx) error) error {
+	ctx := context.Background()
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func hasVersion(files []migrationFile, version int) bool {
+	for _, file := range files {
+		if file.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Statement is one statement of a migration and the line of the file it starts on.
+type Statement struct {
+	SQL  string
+	Line int
+}
+
+// splitter walks a migration the way Postgres reads it, so a delimiter inside a string, a
+// quoted identifier, a dollar-quoted body or a comment doesn't end the statement.
+type splitter struct {
+	src  string
+	pos  int
+	line int
+
+	statements []Statement
+	// start is where the current statement's first token is, -1 until there is one
+	start     int
+	startLine int
+	delimiter string
+}
+
+// parseSQLStatements splits a migration into its statements, in the order they appear.
+// A line reading `-- delimiter //` ends the next statement with // instead of ;, which
+// older migrations use around function bodies.
+func parseSQLStatements(body []byte) ([]Statement, error) {
+	if strings.TrimSpace(string(body)) == "" {
+		return nil, errors.New("migration file is empty")
+	}
+
+	s := &splitter{src: string(body), line: 1, start: -1, delimiter: ";"}
+	if err := s.split(); err != nil {
+		return nil, err
+	}
+	return s.statements, nil
+}
+
+func (s *splitter) split() error {
+	for s.pos < len(s.src) {
+		switch c := s.src[s.pos]; {
+		case s.start == -1 && c == '-' && s.hasPrefix("-- delimiter"):
+			s.readDelimiterDirective()
+		case c == '-' && s.hasPrefix("--"):
+			s.skipLineComment()
+		case c == '/' && s.hasPrefix("/*"):
+			if err := s.skipBlockComment(); err != nil {
+				return err
+			}
+		case s.hasPrefix(s.delimiter):
+			s.endStatement()
+			s.pos += len(s.delimiter)
+			s.delimiter = ";"
+		case c == '\n' || c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			s.advance(1)
+		default:
+			if err := s.readToken(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the last statement may go without a delimiter
+	s.endStatement()
+	return nil
+}
+
+// readToken reads a string, a quoted identifier, a dollar-quoted body or a single other
+// character of the current statement.
+func (s *splitter) readToken() error {
+	if s.start == -1 {
+		s.start = s.pos
+		s.startLine = s.line
+	}
+
+	c := s.src[s.pos]
+	switch {
+	case (c == 'E' || c == 'e') && s.peek(1) == '\'' && !s.followsIdentifier():
+		s.advance(1)
+		return s.readQuoted('\'', true, "string")
+	case c == '\'':
+		return s.readQuoted('\'', false, "string")
+	case c == '"':
+		return s.readQuoted('"', false, "quoted identifier")
+	case c == '$' && !s.followsIdentifier():
+		if tag, ok := s.dollarTag(); ok {
+			return s.readDollarQuoted(tag)
+		}
+	}
+	s.advance(1)
+	return nil
+}
+
+// readQuoted reads up to the closing quote, where a doubled quote stands for itself and,
+// in an E-string, a backslash escapes the next character.
+func (s *splitter) readQuoted(quote byte, backslashEscapes bool, kind string) error {
+	line := s.line
+	s.advance(1)
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case backslashEscapes && c == '\\':
+			s.advance(min(2, len(s.src)-s.pos))
+		case c == quote && s.peek(1) == quote:
+			s.advance(2)
+		case c == quote:
+			s.advance(1)
+			return nil
+		default:
+			s.advance(1)
+		}
+	}
+	return fmt.Errorf("unterminated %s starting on line %d", kind, line)
+}
+
+// dollarTag reads the $tag$ opening a dollar-quoted body, which a positional parameter
+// like $1 is not.
+func (s *splitter) dollarTag() (string, bool) {
+	end := s.pos + 1
+	// a tag is an identifier without the $ identifiers may otherwise contain
+	for end < len(s.src) && s.src[end] != '$' && isIdentifierChar(s.src[end]) {
+		end++
+	}
+	if end >= len(s.src) || s.src[end] != '$' {
+		return "", false
+	}
+	if end > s.pos+1 && isDigit(s.src[s.pos+1]) {
+		return "", false
+	}
+	return s.src[s.pos : end+1], true
+}
+
+func (s *splitter) readDollarQuoted(tag string) error {
+	line := s.line
+	s.advance(len(tag))
+	end := strings.Index(s.src[s.pos:], tag)
+	if end == -1 {
+		return fmt.Errorf("unterminated dollar-quoted string %s starting on line %d", tag, line)
+	}
+	s.advance(end + len(tag))
+	return nil
+}
+
+func (s *splitter) skipLineComment() {
+	end := strings.IndexByte(s.src[s.pos:], '\n')
+	if end == -1 {
+		end = len(s.src) - s.pos
+	}
+	s.advance(end)
+}
+
+// skipBlockComment skips a /* */ comment, which can nest in Postgres.
+func (s *splitter) skipBlockComment() error {
+	line := s.line
+	depth := 0
+	for s.pos < len(s.src) {
+		switch {
+		case s.hasPrefix("/*"):
+			depth++
+			s.advance(2)
+		case s.hasPrefix("*/"):
+			depth--
+			s.advance(2)
+			if depth == 0 {
+				return nil
+			}
+		default:
+			s.advance(1)
+		}
+	}
+	return fmt.Errorf("unterminated block comment starting on line %d", line)
+}
+
+func (s *splitter) readDelimiterDirective() {
+	end := strings.IndexByte(s.src[s.pos:], '\n')
+	if end == -1 {
+		end = len(s.src) - s.pos
+	}
+	parts := strings.Fields(s.src[s.pos : s.pos+end])
+	if len(parts) == 3 {
+		s.delimiter = parts[2]
+	}
+	s.advance(end)
+}
+
+func (s *splitter) endStatement() {
+	if s.start == -1 {
+		return
+	}
+	s.statements = append(s.statements, Statement{
+		SQL:  strings.TrimSpace(s.src[s.start:s.pos]),
+		Line: s.startLine,
+	})
+	s.start = -1
+}
+
+// advance moves n bytes ahead, counting the lines it passes.
+func (s *splitter) advance(n int) {
+	s.line += strings.Count(s.src[s.pos:s.pos+n], "\n")
+	s.pos += n
+}
+
+func (s *splitter) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(s.src[s.pos:], prefix)
+}
+
+func (s *splitter) peek(offset int) byte {
+	if s.pos+offset >= len(s.src) {
+		return 0
+	}
+	return s.src[s.pos+offset]
+}
+
+// followsIdentifier tells whether the current character continues an identifier, like the
+// e of name' or the $ of a$b.
+func (s *splitter) followsIdentifier() bool {
+	return s.pos > 0 && isIdentifierChar(s.src[s.pos-1])
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// statementError names the line a failed statement starts on, or the line of the error
+// itself when Postgres reports where in the statement it is.
+func statementError(statement Statement, err error) error {
+	line := statement.Line
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Position > 0 {
+		runes := []rune(statement.SQL)
+		position := min(int(pgErr.Position)-1, len(runes))
+		line += strings.Count(string(runes[:position]), "\n")
+	}
+	return fmt.Errorf("statement on line %d: %w", line, err)
+}
@@ -0,0 +1,254 @@
+package migration
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/mhvn092/movie-go/migrations"
+)
+
+func TestParseSQLStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []Statement
+	}{
+		{
+			name: "statements on separate lines",
+			sql:  "CREATE TABLE a (id int);\n\nCREATE TABLE b (id int);\n",
+			want: []Statement{{"CREATE TABLE a (id int)", 1}, {"CREATE TABLE b (id int)", 3}},
+		},
+		{
+			name: "statements on one line",
+			sql:  "SELECT 1; SELECT 2;SELECT 3;",
+			want: []Statement{{"SELECT 1", 1}, {"SELECT 2", 1}, {"SELECT 3", 1}},
+		},
+		{
+			name: "statement spanning lines",
+			sql:  "SELECT 1;\nINSERT INTO a\n  VALUES (1);",
+			want: []Statement{{"SELECT 1", 1}, {"INSERT INTO a\n  VALUES (1)", 2}},
+		},
+		{
+			name: "last statement without a delimiter",
+			sql:  "SELECT 1;\nSELECT 2\n",
+			want: []Statement{{"SELECT 1", 1}, {"SELECT 2", 2}},
+		},
+		{
+			name: "semicolon in a string",
+			sql:  "INSERT INTO a VALUES ('x;y');",
+			want: []Statement{{"INSERT INTO a VALUES ('x;y')", 1}},
+		},
+		{
+			name: "doubled quote in a string",
+			sql:  "SELECT 'it''s; fine';SELECT 2;",
+			want: []Statement{{"SELECT 'it''s; fine'", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "backslash escape in an E-string",
+			sql:  "SELECT E'it\\'s; fine', e'\\\\';SELECT 2;",
+			want: []Statement{{"SELECT E'it\\'s; fine', e'\\\\'", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "backslash in a plain string",
+			sql:  "SELECT 'a\\';SELECT 2;",
+			want: []Statement{{"SELECT 'a\\'", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "identifier ending in e before a string",
+			sql:  "SELECT name'a\\';SELECT 2;",
+			want: []Statement{{"SELECT name'a\\'", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "semicolon in a quoted identifier",
+			sql:  "CREATE TABLE \"a;\"\"b\" (id int);",
+			want: []Statement{{"CREATE TABLE \"a;\"\"b\" (id int)", 1}},
+		},
+		{
+			name: "dollar-quoted body",
+			sql:  "CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\nSELECT 2;",
+			want: []Statement{
+				{"CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql", 1},
+				{"SELECT 2", 6},
+			},
+		},
+		{
+			name: "tagged dollar quote containing $$",
+			sql:  "SELECT $fn$ a; $$ b; $fn$;SELECT 2;",
+			want: []Statement{{"SELECT $fn$ a; $$ b; $fn$", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "positional parameters are not dollar quotes",
+			sql:  "SELECT $1, $2;SELECT 2;",
+			want: []Statement{{"SELECT $1, $2", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "dollar inside an identifier",
+			sql:  "SELECT a$b$ FROM t;SELECT 2;",
+			want: []Statement{{"SELECT a$b$ FROM t", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name: "line comments",
+			sql:  "-- a comment; not a statement\nSELECT 1; -- trailing; comment\nSELECT 2;",
+			want: []Statement{{"SELECT 1", 2}, {"SELECT 2", 3}},
+		},
+		{
+			name: "nested block comments",
+			sql:  "/* outer; /* inner; */ still; */ SELECT 1;\n/*\n;\n*/SELECT 2;",
+			want: []Statement{{"SELECT 1", 1}, {"SELECT 2", 4}},
+		},
+		{
+			name: "comment inside a statement",
+			sql:  "SELECT 1 /* ; */ + 1;",
+			want: []Statement{{"SELECT 1 /* ; */ + 1", 1}},
+		},
+		{
+			name: "quotes inside comments",
+			sql:  "-- it's\n/* \"x $$ */SELECT 1;",
+			want: []Statement{{"SELECT 1", 2}},
+		},
+		{
+			name: "delimiter directive",
+			sql:  "SELECT 1;\n-- delimiter //\nSELECT 2; SELECT 3//\n-- delimiter ;\nSELECT 4;",
+			want: []Statement{{"SELECT 1", 1}, {"SELECT 2; SELECT 3", 3}, {"SELECT 4", 5}},
+		},
+		{
+			name: "delimiter resets after one statement",
+			sql:  "-- delimiter //\nSELECT 1//\nSELECT 2;",
+			want: []Statement{{"SELECT 1", 2}, {"SELECT 2", 3}},
+		},
+		{
+			name: "delimiter comment inside a statement",
+			sql:  "SELECT 1\n-- delimiter //\n;SELECT 2;",
+			want: []Statement{{"SELECT 1\n-- delimiter //", 1}, {"SELECT 2", 3}},
+		},
+		{
+			name: "crlf line endings",
+			sql:  "SELECT 1;\r\nSELECT 2;\r\n",
+			want: []Statement{{"SELECT 1", 1}, {"SELECT 2", 2}},
+		},
+		{
+			name: "empty statements",
+			sql:  ";;SELECT 1;;",
+			want: []Statement{{"SELECT 1", 1}},
+		},
+		{
+			name: "only comments",
+			sql:  "-- nothing to do\n/* at all */\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSQLStatements([]byte(tt.sql))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSQLStatementsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"empty file", " \n\t\n", "migration file is empty"},
+		{"unterminated string", "SELECT 1;\nSELECT 'a;", "unterminated string starting on line 2"},
+		{"unterminated E-string", "SELECT E'a\\';", "unterminated string starting on line 1"},
+		{"unterminated quoted identifier", "\n\nSELECT \"a;", "unterminated quoted identifier starting on line 3"},
+		{"unterminated dollar quote", "SELECT 1;\n\nSELECT $fn$ a; $$", "unterminated dollar-quoted string $fn$ starting on line 3"},
+		{"unterminated block comment", "SELECT 1;\n/* /* */", "unterminated block comment starting on line 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSQLStatements([]byte(tt.sql))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// migrationStatementCounts pins how the existing migrations split, including the ones
+// written with the delimiter directive.
+var migrationStatementCounts = map[string]int{
+	"up/8_add-search-index-for-staff.sql":                  3,
+	"up/10_add_search_vector_for_movie.sql":                3,
+	"up/11_add_search_vector_update_trigger_for_movie.sql": 3,
+	"up/16_add-movie-review.sql":                           5,
+	"up/21_add-role-and-permission.sql":                    10,
+	"up/25_add-two-factor.sql":                             3,
+	"down/8_add-search-index-for-staff.sql":                3,
+	"down/10_add_search_vector_for_movie.sql":              3,
+	"down/16_add-movie-review.sql":                         4,
+	"down/21_add-role-and-permission.sql":                  5,
+	"down/25_add-two-factor.sql":                           4,
+}
+
+func TestParseMigrationFiles(t *testing.T) {
+	files := 0
+	err := fs.WalkDir(migrations.FS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".sql") {
+			return err
+		}
+		files++
+
+		body, err := fs.ReadFile(migrations.FS, path)
+		if err != nil {
+			return err
+		}
+		statements, err := parseSQLStatements(body)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		if len(statements) == 0 {
+			t.Errorf("%s: no statements", path)
+		}
+		if want, ok := migrationStatementCounts[path]; ok && len(statements) != want {
+			t.Errorf("%s: got %d statements, want %d", path, len(statements), want)
+		}
+		for _, statement := range statements {
+			if strings.Contains(statement.SQL, "-- delimiter") || strings.HasSuffix(statement.SQL, "//") {
+				t.Errorf("%s: delimiter left in the statement on line %d", path, statement.Line)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files == 0 {
+		t.Fatal("no migration files found")
+	}
+}
+
+func TestStatementError(t *testing.T) {
+	statement := Statement{SQL: "SELECT 1,\n  'é',\n  nope", Line: 4}
+	cause := errors.New("connection lost")
+
+	err := statementError(statement, cause)
+	if !errors.Is(err, cause) || err.Error() != "statement on line 4: connection lost" {
+		t.Errorf("got %v", err)
+	}
+
+	// Postgres counts characters, not bytes, from 1
+	position := utf8.RuneCountInString(statement.SQL[:strings.Index(statement.SQL, "nope")]) + 1
+	pgErr := &pgconn.PgError{Message: "column does not exist", Position: int32(position)}
+	err = statementError(statement, pgErr)
+	if !strings.HasPrefix(err.Error(), "statement on line 6: ") {
+		t.Errorf("got %v", err)
+	}
+}
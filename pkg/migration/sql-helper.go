@@ -1,77 +1,21 @@
 package migration
 
-import (
-	"bufio"
-	"errors"
-	"os"
-	"strings"
-
-	"github.com/mhvn092/movie-go/pkg/exception"
-)
-
-func parseSQLStatements(file *os.File) map[int]string {
-	scanner := bufio.NewScanner(file)
-	sqlStatements := make(map[int]string)
-	var sqlBuilder strings.Builder
-	statementID := 0
-	hasContent := false
-	currentDelimiter := ";"
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmedLine := strings.TrimSpace(line)
-		if trimmedLine == "" {
-			continue
-		}
-		hasContent = true
-
-		if strings.HasPrefix(trimmedLine, `-- delimiter`) {
-			parts := strings.Fields(trimmedLine)
-			if len(parts) == 3 && parts[1] == "delimiter" {
-				currentDelimiter = parts[2]
-			}
-			continue
-		}
-
-		sqlBuilder.WriteString(trimmedLine)
-		sqlBuilder.WriteString(" ")
-
-		if strings.HasSuffix(trimmedLine, currentDelimiter) {
-			stmt := strings.TrimSpace(sqlBuilder.String())
-			stmt = strings.TrimSuffix(stmt, currentDelimiter)
-			sqlStatements[statementID] = strings.TrimSpace(stmt)
-			sqlBuilder.Reset()
-			statementID++
-			currentDelimiter = ";"
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		exception.ErrorExit(err, "Failed to scan migration file: %v\n")
-	}
-
-	// Add any remaining SQL statement that doesn't end with a semicolon
-	if sqlBuilder.Len() > 0 {
-		sqlStatements[statementID] = sqlBuilder.String()
-	}
-
-	if !hasContent {
-		exception.ErrorExit(errors.New("migration file is empty"), "Empty file")
-	}
-
-	return sqlStatements
+func getAllMigrationQuery() string {
+	return `SELECT name, applied_at, checksum FROM migrations ORDER BY applied_at ASC;`
 }
 
-func getLastMigrationQuery() string {
-	return `SELECT name FROM migrations order by applied_at DESC LIMIT 1;`
+// getAllMigrationWithoutChecksumQuery reads a migrations table from before checksums were kept.
+func getAllMigrationWithoutChecksumQuery() string {
+	return `SELECT name, applied_at, NULL::varchar FROM migrations ORDER BY applied_at ASC;`
 }
 
-func getAllMigrationQuery() string {
-	return `SELECT name FROM migrations ORDER BY applied_at ASC;`
+func migrationTableStateQuery() string {
+	return `SELECT to_regclass('migrations') IS NOT NULL,
+			EXISTS (SELECT 1 FROM pg_attribute WHERE attrelid = to_regclass('migrations') AND attname = 'checksum' AND NOT attisdropped);`
 }
 
 func getUpdatingMigrationTableQuery(revert bool) string {
-	migrationTableStatement := `INSERT INTO migrations (name) VALUES ($1)`
+	migrationTableStatement := `INSERT INTO migrations (name, checksum) VALUES ($1, $2)`
 	if revert {
 		migrationTableStatement = `DELETE FROM migrations where name = $1`
 	}
@@ -85,3 +29,12 @@ func checkExistenceOfMigrationTableQuery() string {
 			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 			);`
 }
+
+// addChecksumColumnQuery upgrades a migrations table from before checksums were kept.
+func addChecksumColumnQuery() string {
+	return `ALTER TABLE migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);`
+}
+
+func updateChecksumQuery() string {
+	return `UPDATE migrations SET checksum = $2 WHERE name = $1;`
+}
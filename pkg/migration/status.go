@@ -0,0 +1,99 @@
+package migration
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Status of a migration, which is applied, pending, or applied but changed or gone from the directory since.
+type Status struct {
+	Name      string
+	Version   int
+	AppliedAt *time.Time
+	Missing   bool
+	// Changed tells the file is no longer the one that was applied
+	Changed bool
+}
+
+func (s Status) State() string {
+	switch {
+	case s.Missing:
+		return "missing file"
+	case s.Changed:
+		return "changed"
+	case s.AppliedAt != nil:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
+
+// Status lists every migration in the directory, and every applied one that is no longer
+// there, by version.
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureMigrationTable(); err != nil {
+		return nil, err
+	}
+	appliedMigrations, err := m.readAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	files, err := m.readMigrationsSorted()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	names := map[string]bool{}
+	for _, file := range files {
+		status := Status{Name: file.Name, Version: file.Version}
+		if applied, ok := appliedMigrations[file.Name]; ok {
+			checksum, err := m.fileChecksum(file.Name)
+			if err != nil {
+				return nil, err
+			}
+			status.AppliedAt = &applied.AppliedAt
+			status.Changed = applied.Checksum != nil && *applied.Checksum != checksum
+		}
+		statuses = append(statuses, status)
+		names[file.Name] = true
+	}
+
+	for name, applied := range appliedMigrations {
+		if names[name] {
+			continue
+		}
+		version, err := migrationVersion(name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{
+			Name:      name,
+			Version:   version,
+			AppliedAt: &applied.AppliedAt,
+			Missing:   true,
+		})
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// PrintStatus writes the statuses as a table.
+func PrintStatus(out io.Writer, statuses []Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, status.State(), appliedAt)
+	}
+	w.Flush()
+}
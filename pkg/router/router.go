@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/mhvn092/movie-go/internal/platform/middleware"
@@ -51,7 +50,14 @@ func (r *Router) handleMethod(
 	// Wrap the handlerFunc to enforce HTTP method
 	baseHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != method {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			w.Header().Set("Allow", method)
+			exception.HttpError(
+				errors.New("Method Not Allowed"),
+				w,
+				req,
+				"Method Not Allowed",
+				http.StatusMethodNotAllowed,
+			)
 			return
 		}
 		handlerFunc(w, req)
@@ -70,48 +76,27 @@ func (r *Router) handleMethod(
 	r.mux.Handle(path, handler)
 }
 
+// GetWithPagination parses limit, sort, cursor and with_total against spec and hands the
+// resulting web.PaginationParam to the handler through the request context.
 func (r *Router) GetWithPagination(
 	path string,
+	spec web.PaginationSpec,
 	handlerFunc http.HandlerFunc,
 	middlewares ...middleware.Middleware,
 ) {
 	wrapped := func(w http.ResponseWriter, req *http.Request) {
-		query := req.URL.Query()
-
-		limitStr := query.Get("limit")
-		cursorStr := query.Get("cursor_id")
-
-		var (
-			limit  uint64 = 20
-			cursor uint64 = 0
-			err    error
-		)
-
-		if limitStr != "" {
-			if limit, err = strconv.ParseUint(limitStr, 10, 64); err != nil || limit == 0 {
-				exception.HttpError(
-					errors.New("Invalid parameter"),
-					w,
-					"Invalid parameter",
-					http.StatusBadRequest,
-				)
-				return
-			}
-		}
-
-		if cursorStr != "" {
-			if cursor, err = strconv.ParseUint(cursorStr, 10, 64); err != nil {
-				exception.HttpError(
-					errors.New("Invalid parameter"),
-					w,
-					"Invalid parameter",
-					http.StatusBadRequest,
-				)
-				return
-			}
+		param, fieldErrors := web.ParsePaginationParam(req.URL.Query(), spec)
+		if len(fieldErrors) > 0 {
+			exception.HttpValidationError(
+				errors.New("Invalid parameter"),
+				w,
+				req,
+				"Invalid parameter",
+				fieldErrors,
+			)
+			return
 		}
 
-		param := web.PaginationParam{Limit: limit, CursorID: cursor}
 		ctx := context.WithValue(req.Context(), web.PaginationKey, param)
 		req = req.WithContext(ctx)
 